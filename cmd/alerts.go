@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/notify"
+)
+
+// alerting runs the alerting rules of alerts.rulesFile and sends the alerts
+// to the sinks of alerts.notificationsFile. Both files are reloaded when they
+// change, on SIGHUP and when the settings change.
+type alerting struct {
+	engine     *alerts.Engine
+	dispatcher *notify.Dispatcher
+
+	mu        sync.Mutex
+	rulesFile string
+	stopWatch context.CancelFunc
+
+	notifyMu          sync.Mutex
+	notificationsFile string
+	stopNotifyWatch   context.CancelFunc
+}
+
+// startAlerting starts evaluating the rules until ctx is cancelled. Without a
+// rules file it evaluates nothing until one is configured.
+func startAlerting(ctx context.Context, clientset kubernetes.Interface, reloader *configReloader) *alerting {
+	a := &alerting{
+		engine:     alerts.NewEngine(clientset, cfg.Alerts.Namespace, cfg.Controller.ChunkSize, cfg.Alerts.Interval),
+		dispatcher: newDispatcher(ctx, cfg.Alerts.DeadLetterFile),
+	}
+	a.engine.Changed = func(alert alerts.Alert) {
+		logAlert(alert)
+		a.dispatcher.Notify(alert)
+	}
+	a.engine.Evaluated = recordAlerts
+
+	if err := a.setNotificationsFile(ctx, cfg.Alerts.NotificationsFile); err != nil {
+		log.Error("Failed to load notification sinks", err, map[string]interface{}{
+			"notifications_file": cfg.Alerts.NotificationsFile,
+		})
+	}
+	reloader.handle(func(c *config.Config) error {
+		return a.setNotificationsFile(ctx, c.Alerts.NotificationsFile)
+	}, "alerts.notificationsFile")
+
+	if err := a.setRulesFile(ctx, cfg.Alerts.RulesFile); err != nil {
+		// Validated with the configuration, so only a race with an edit
+		log.Error("Failed to load alert rules", err, map[string]interface{}{
+			"rules_file": cfg.Alerts.RulesFile,
+		})
+	}
+	reloader.handle(func(c *config.Config) error {
+		a.engine.SetInterval(c.Alerts.Interval)
+		return a.setRulesFile(ctx, c.Alerts.RulesFile)
+	}, "alerts.rulesFile", "alerts.interval")
+
+	go a.engine.Run(ctx)
+	return a
+}
+
+// setRulesFile loads the rules of path and watches it for changes
+func (a *alerting) setRulesFile(ctx context.Context, path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopWatch != nil {
+		a.stopWatch()
+		a.stopWatch = nil
+	}
+	a.rulesFile = path
+	if path == "" {
+		return a.engine.SetRules(nil)
+	}
+
+	if err := a.load(); err != nil {
+		return err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	a.stopWatch = cancel
+	err := config.Watch(watchCtx, path, func(trigger string) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if err := a.load(); err != nil {
+			log.Error("Alert rules reload rejected; keeping the running rules", err, map[string]interface{}{
+				"trigger":    trigger,
+				"rules_file": a.rulesFile,
+			})
+		}
+	})
+	if err != nil {
+		log.Error("Failed to watch alert rules; reload with SIGHUP", err, map[string]interface{}{
+			"rules_file": path,
+		})
+	}
+	return nil
+}
+
+// load reads the rules file and replaces the running rules
+func (a *alerting) load() error {
+	rules, err := alerts.LoadRules(a.rulesFile)
+	if err != nil {
+		return err
+	}
+	if err := a.engine.SetRules(rules); err != nil {
+		return err
+	}
+	log.Info("Alert rules loaded", map[string]interface{}{
+		"rules_file": a.rulesFile,
+		"rules":      len(rules),
+	})
+	return nil
+}
+
+// setNotificationsFile loads the sinks of path and watches it for changes
+func (a *alerting) setNotificationsFile(ctx context.Context, path string) error {
+	a.notifyMu.Lock()
+	defer a.notifyMu.Unlock()
+
+	if a.stopNotifyWatch != nil {
+		a.stopNotifyWatch()
+		a.stopNotifyWatch = nil
+	}
+	a.notificationsFile = path
+	if path == "" {
+		return a.dispatcher.SetSinks(nil)
+	}
+
+	if err := a.loadSinks(); err != nil {
+		return err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	a.stopNotifyWatch = cancel
+	err := config.Watch(watchCtx, path, func(trigger string) {
+		a.notifyMu.Lock()
+		defer a.notifyMu.Unlock()
+		if err := a.loadSinks(); err != nil {
+			log.Error("Notification sinks reload rejected; keeping the running sinks", err, map[string]interface{}{
+				"trigger":            trigger,
+				"notifications_file": a.notificationsFile,
+			})
+		}
+	})
+	if err != nil {
+		log.Error("Failed to watch notification sinks; reload with SIGHUP", err, map[string]interface{}{
+			"notifications_file": path,
+		})
+	}
+	return nil
+}
+
+// loadSinks reads the notifications file and replaces the running sinks
+func (a *alerting) loadSinks() error {
+	sinks, err := notify.Load(a.notificationsFile)
+	if err != nil {
+		return err
+	}
+	if err := a.dispatcher.SetSinks(sinks); err != nil {
+		return err
+	}
+	log.Info("Notification sinks loaded", map[string]interface{}{
+		"notifications_file": a.notificationsFile,
+		"sinks":              len(sinks),
+	})
+	return nil
+}
+
+// newDispatcher creates a dispatcher that records deliveries in the metrics
+// and logs failures. Dead letters are also appended to deadLetterFile, if set.
+func newDispatcher(ctx context.Context, deadLetterFile string) *notify.Dispatcher {
+	var letters *notify.DeadLetterFile
+	if deadLetterFile != "" {
+		var err error
+		if letters, err = notify.OpenDeadLetterFile(deadLetterFile); err != nil {
+			log.Error("Dead letters will only be logged", err, map[string]interface{}{
+				"dead_letter_file": deadLetterFile,
+			})
+		}
+	}
+
+	d := notify.NewDispatcher(ctx)
+	d.Sent = func(sink string, alert alerts.Alert, attempts int) {
+		metrics.Notifications.WithLabelValues(sink, "sent").Inc()
+		log.Debug("Notification sent", map[string]interface{}{
+			"sink":     sink,
+			"rule":     alert.Rule,
+			"state":    alert.State,
+			"attempts": attempts,
+		})
+	}
+	d.Failed = func(sink string, alert alerts.Alert, attempt int, err error) {
+		metrics.Notifications.WithLabelValues(sink, "failed").Inc()
+		log.Warn("Notification failed", map[string]interface{}{
+			"sink":    sink,
+			"rule":    alert.Rule,
+			"attempt": attempt,
+			"error":   err.Error(),
+		})
+	}
+	d.DeadLettered = func(letter notify.DeadLetter) {
+		metrics.Notifications.WithLabelValues(letter.Sink, "dead_lettered").Inc()
+		log.Error("Notification dead-lettered", errors.New(letter.Error), map[string]interface{}{
+			"sink":      letter.Sink,
+			"rule":      letter.Alert.Rule,
+			"state":     letter.Alert.State,
+			"namespace": letter.Alert.Namespace,
+			"name":      letter.Alert.Name,
+			"attempts":  letter.Attempts,
+		})
+		if letters != nil {
+			if err := letters.Write(letter); err != nil {
+				log.Error("Failed to write dead letter", err, map[string]interface{}{
+					"dead_letter_file": deadLetterFile,
+				})
+			}
+		}
+	}
+	return d
+}
+
+// logAlert logs an alert that fired or resolved
+func logAlert(alert alerts.Alert) {
+	alertLogger := log.WithNamespace(alert.Namespace)
+	fields := map[string]interface{}{
+		"rule":          alert.Rule,
+		"severity":      alert.Severity,
+		"kind":          alert.Kind,
+		"name":          alert.Name,
+		"alert_message": alert.Message,
+	}
+	if alert.State == alerts.StateResolved {
+		alertLogger.Info("Alert resolved", fields)
+		return
+	}
+	alertLogger.Warn("Alert firing", fields)
+}
+
+// recordAlerts updates the alert metrics after an evaluation
+func recordAlerts(current []alerts.Alert, err error) {
+	if err != nil {
+		metrics.AlertEvaluations.WithLabelValues("failure").Inc()
+		log.Error("Failed to evaluate alert rules", err, nil)
+		return
+	}
+	metrics.AlertEvaluations.WithLabelValues("success").Inc()
+
+	metrics.Alerts.Reset()
+	for _, alert := range current {
+		if alert.State != alerts.StateResolved {
+			metrics.Alerts.WithLabelValues(alert.Rule, alert.Severity, alert.State).Inc()
+		}
+	}
+}
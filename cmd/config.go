@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+)
+
+// defaultConfigFile is loaded when it exists and no other file is given
+const defaultConfigFile = ".k8s-controller-tutorial.yaml"
+
+var (
+	// cfg is the effective configuration. Flags are bound to its fields, so
+	// after flag parsing it holds defaults < config file < environment < flags.
+	cfg = config.Default()
+
+	configFile   string
+	configOutput string
+)
+
+// configCmd groups the configuration subcommands
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long: `Inspect the effective configuration.
+
+Settings are layered, each layer overriding the previous one:
+  1. built-in defaults
+  2. the config file (--config, $` + config.EnvConfigFile + ` or ~/` + defaultConfigFile + `)
+  3. environment variables (` + config.EnvPrefix + `_SERVER_PORT for server.port)
+  4. command-line flags`,
+	// Viewing the configuration needs no logging or tracing setup
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+// configViewCmd prints the effective configuration
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the effective configuration with secrets masked",
+	Long: `Print the effective configuration with secrets masked. The output can be
+used as a config file. Invalid settings are reported after the output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := config.Marshal(cfg, configOutput)
+		if err != nil {
+			return err
+		}
+		if _, err := cmd.OutOrStdout().Write(data); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return cfg.Validate()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configViewCmd)
+	configViewCmd.Flags().StringVarP(&configOutput, "output", "o", config.FormatYAML, "Output format: yaml, json or toml")
+}
+
+// loadConfig applies the config file and environment variables to cfg. It
+// runs before cobra parses the flags, so that flags take precedence; the
+// config file is therefore looked up in args directly.
+func loadConfig(args []string) error {
+	path, explicit := configFlag(args)
+	if !explicit {
+		path, explicit = os.LookupEnv(config.EnvConfigFile)
+	}
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, defaultConfigFile)
+		}
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err == nil || explicit {
+			if err := config.LoadFile(path, cfg); err != nil {
+				return err
+			}
+			configFile = path
+		}
+	}
+
+	return config.LoadEnv(os.Environ(), cfg)
+}
+
+// configFlag finds the value of --config in command-line arguments
+func configFlag(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return value, true
+		}
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// logConfigSource records where the configuration came from
+func logConfigSource() {
+	source := "defaults"
+	if configFile != "" {
+		source = configFile
+	}
+	log.Debug("Configuration loaded", map[string]interface{}{
+		"config_file": source,
+	})
+}
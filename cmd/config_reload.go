@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+)
+
+// startupConfig is the configuration before flags were parsed; settings that
+// differ from it after parsing were set by a flag
+var startupConfig *config.Config
+
+// configReloadedAt is the time of the last successful reload
+var configReloadedAt atomic.Pointer[time.Time]
+
+// configReloader applies changes of the config file to a running process.
+// Settings are reloaded with the same layers as at startup, so flags keep
+// overriding the file and the environment. Handlers apply groups of settings
+// by key prefix; changed settings without a handler need a restart.
+type configReloader struct {
+	mu       sync.Mutex
+	applied  *config.Config
+	flagKeys map[string]bool
+	handlers []reloadHandler
+}
+
+// reloadHandler applies the settings under prefixes
+type reloadHandler struct {
+	prefixes []string
+	apply    func(c *config.Config) error
+}
+
+// newConfigReloader starts from the effective configuration. The log
+// settings are reloadable in every command.
+func newConfigReloader() *configReloader {
+	r := &configReloader{
+		applied:  cfg.Clone(),
+		flagKeys: make(map[string]bool),
+	}
+	if startupConfig != nil {
+		for _, key := range config.Diff(startupConfig, cfg) {
+			r.flagKeys[key] = true
+		}
+	}
+
+	r.handle(func(c *config.Config) error { return log.SetLevel(c.Log.Level) }, "log.level")
+	r.handle(func(c *config.Config) error { return log.SetSampling(c.Log.Sampling) }, "log.sampling")
+	r.handle(func(c *config.Config) error { return log.SetRedaction(c.Log.Redaction) }, "log.redaction")
+	return r
+}
+
+// handle registers apply for the settings under prefixes; it is called once
+// per reload when any of them changed
+func (r *configReloader) handle(apply func(c *config.Config) error, prefixes ...string) {
+	r.handlers = append(r.handlers, reloadHandler{prefixes: prefixes, apply: apply})
+}
+
+// watch reloads on config file changes and SIGHUP until ctx is cancelled
+func (r *configReloader) watch(ctx context.Context) {
+	if err := config.Watch(ctx, configFile, r.reload); err != nil {
+		log.Error("Failed to watch config file; reload with SIGHUP", err, map[string]interface{}{
+			"config_file": configFile,
+		})
+		if err := config.Watch(ctx, "", r.reload); err != nil {
+			log.Error("Failed to watch for SIGHUP", err, nil)
+		}
+		return
+	}
+	log.Debug("Watching configuration for changes", map[string]interface{}{
+		"config_file": configFile,
+	})
+}
+
+// reload loads, validates and applies the configuration. An invalid
+// configuration is rejected as a whole and the running one is kept.
+func (r *configReloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"trigger":     trigger,
+		"config_file": configFile,
+	}
+
+	next, err := r.load()
+	if err != nil {
+		metrics.ConfigReloads.WithLabelValues("failure").Inc()
+		log.Error("Configuration reload rejected; keeping the running configuration", err, fields)
+		return
+	}
+
+	changed := config.Diff(r.applied, next)
+	if len(changed) == 0 {
+		metrics.ConfigReloads.WithLabelValues("success").Inc()
+		log.Info("Configuration reloaded without changes", fields)
+		return
+	}
+
+	applied := make(map[string]bool, len(changed))
+	for _, h := range r.handlers {
+		keys := keysUnder(changed, h.prefixes)
+		if len(keys) == 0 {
+			continue
+		}
+		for _, key := range keys {
+			applied[key] = true
+		}
+		if err := h.apply(next); err != nil {
+			// Validation passed, so this is not expected; the other
+			// handlers still run so the process stays consistent
+			log.Error("Failed to apply reloaded settings", err, map[string]interface{}{
+				"keys": strings.Join(keys, ","),
+			})
+		}
+	}
+
+	var restart []string
+	for _, key := range changed {
+		if !applied[key] {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		log.Warn("Changed settings require a restart to take effect", map[string]interface{}{
+			"keys": strings.Join(restart, ","),
+		})
+		// Keep the running value, so the warning repeats until a restart
+		config.Overlay(next, r.applied, restart)
+	}
+
+	r.applied = next
+	now := time.Now().UTC()
+	configReloadedAt.Store(&now)
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+
+	fields["changed"] = strings.Join(changed, ",")
+	log.Info("Configuration reloaded", fields)
+}
+
+// load builds the configuration from the same layers as at startup
+func (r *configReloader) load() (*config.Config, error) {
+	next := config.Default()
+	if configFile != "" {
+		if err := config.LoadFile(configFile, next); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.LoadEnv(os.Environ(), next); err != nil {
+		return nil, err
+	}
+
+	// Flags keep overriding the file and the environment
+	var overridden []string
+	for _, key := range config.Diff(r.applied, next) {
+		if r.flagKeys[key] {
+			overridden = append(overridden, key)
+		}
+	}
+	if len(overridden) > 0 {
+		log.Debug("Reloaded settings are overridden by flags", map[string]interface{}{
+			"keys": strings.Join(overridden, ","),
+		})
+	}
+	config.Overlay(next, r.applied, overridden)
+
+	return next, next.Validate()
+}
+
+// keysUnder returns the keys equal to one of prefixes or nested below it
+func keysUnder(keys []string, prefixes []string) []string {
+	var under []string
+	for _, key := range keys {
+		for _, prefix := range prefixes {
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				under = append(under, key)
+				break
+			}
+		}
+	}
+	return under
+}
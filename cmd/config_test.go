@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	saved, savedFile := cfg.Clone(), configFile
+	defer func() { *cfg, configFile = *saved, savedFile }()
+	*cfg = *config.Default()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+controller:
+  namespace: from-file
+  chunkSize: 100
+log:
+  level: error
+  redaction:
+    valuePatterns: ["from-file"]
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("K8S_CONTROLLER_CONTROLLER_CHUNK_SIZE", "200")
+	t.Setenv("K8S_CONTROLLER_LOG_LEVEL", "warn")
+	t.Setenv("K8S_CONTROLLER_LOG_REDACTION_VALUE_PATTERNS", `["from-env{1,2}"]`)
+
+	args := []string{"--config", path, "--log-level=debug", "--log-redact-values", "[0-9]{3,4}"}
+	if err := loadConfig(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := rootCmd.PersistentFlags().Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		got, want interface{}
+	}{
+		{"server.port (default)", cfg.Server.Port, config.Default().Server.Port},
+		{"controller.namespace (file)", cfg.Controller.Namespace, "from-file"},
+		{"controller.chunkSize (environment)", cfg.Controller.ChunkSize, int64(200)},
+		{"log.level (flag)", cfg.Log.Level, "debug"},
+		{"log.redaction.valuePatterns (flag)", cfg.Log.Redaction.ValuePatterns, []string{"[0-9]{3,4}"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+	}
+}
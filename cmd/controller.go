@@ -4,21 +4,30 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/cobra"
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/pager"
 
+	"github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/diff"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/healthz"
 	"github.com/yourusername/k8s-controller-tutorial/pkg/logger"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/monitor"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/tracing"
 )
 
 var (
-	namespace string
-	watch     bool
-	log       *logger.Logger
+	log *logger.Logger
 )
 
 // controllerCmd represents the controller command
@@ -32,48 +41,127 @@ along with recent events.`,
 
 func init() {
 	rootCmd.AddCommand(controllerCmd)
-	controllerCmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Namespace to monitor")
-	controllerCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Watch for changes continuously")
+	controllerCmd.Flags().StringVarP(&cfg.Controller.Namespace, "namespace", "n", cfg.Controller.Namespace, "Namespace to monitor")
+	controllerCmd.Flags().StringVarP(&cfg.Controller.LabelSelector, "selector", "l", cfg.Controller.LabelSelector, "Label selector for deployments, e.g. app=web,tier!=cache")
+	controllerCmd.Flags().BoolVarP(&cfg.Controller.Watch, "watch", "w", cfg.Controller.Watch, "Watch for changes continuously")
+	controllerCmd.Flags().Int64Var(&cfg.Controller.ChunkSize, "chunk-size", cfg.Controller.ChunkSize, "Number of deployments fetched per API request")
+	controllerCmd.Flags().BoolVar(&cfg.Controller.IgnoreStatus, "ignore-status", cfg.Controller.IgnoreStatus, "Do not report changes to deployment status in watch mode")
+	controllerCmd.Flags().BoolVar(&cfg.Controller.Monitors, "monitors", cfg.Controller.Monitors, "Reconcile DeploymentMonitor objects in all namespaces in watch mode")
+	controllerCmd.Flags().StringSliceVar(&cfg.Controller.CrossNamespaceMonitors, "cross-namespace-monitors", cfg.Controller.CrossNamespaceMonitors, "Namespaces whose DeploymentMonitors may select other namespaces (reloadable)")
+	controllerCmd.Flags().IntVar(&cfg.Controller.HealthPort, "health-port", cfg.Controller.HealthPort, "Plain HTTP port serving /health, /version, /livez, /readyz, /startupz and /metrics in watch mode (0 disables)")
 
 	// Initialize logger
 	log = logger.New()
 }
 
 func runController(cmd *cobra.Command, args []string) {
-	namespaceLogger := log.WithNamespace(namespace)
+	namespaceLogger := log.WithNamespace(cfg.Controller.Namespace)
 
 	namespaceLogger.Info("Starting Kubernetes Controller", map[string]interface{}{
-		"namespace":  namespace,
-		"watch_mode": watch,
+		"namespace":      cfg.Controller.Namespace,
+		"label_selector": cfg.Controller.LabelSelector,
+		"watch_mode":     cfg.Controller.Watch,
 	})
 
 	clientset, err := getKubernetesClient()
 	if err != nil {
 		log.Fatal("Failed to get Kubernetes client", err, map[string]interface{}{
-			"namespace": namespace,
+			"namespace": cfg.Controller.Namespace,
 		})
 	}
 
-	if watch {
-		watchDeployments(clientset, namespaceLogger)
+	if cfg.Controller.Watch {
+		// The namespace and selector can change while watching
+		targets := make(chan watchTarget, 1)
+		reloader := newConfigReloader()
+		reloader.handle(func(c *config.Config) error {
+			select {
+			case <-targets:
+			default:
+			}
+			targets <- watchTarget{namespace: c.Controller.Namespace, labelSelector: c.Controller.LabelSelector}
+			return nil
+		}, "controller.namespace", "controller.labelSelector")
+		var ignoreStatus atomic.Bool
+		ignoreStatus.Store(cfg.Controller.IgnoreStatus)
+		reloader.handle(func(c *config.Config) error {
+			ignoreStatus.Store(c.Controller.IgnoreStatus)
+			return nil
+		}, "controller.ignoreStatus")
+		alerting := startAlerting(cmd.Context(), clientset, reloader)
+		var reconciler *monitor.Reconciler
+		if cfg.Controller.Monitors {
+			if reconciler, err = startMonitors(cmd.Context(), clientset, alerting, reloader); err != nil {
+				log.Fatal("Failed to start DeploymentMonitor reconciler", err, nil)
+			}
+		}
+		reloader.watch(cmd.Context())
+
+		if cfg.Controller.HealthPort != 0 {
+			// Ready while the API server answers, the informers have
+			// synced, the watch is alive and the DeploymentMonitor
+			// reconciler runs; started once the first watch is established
+			// and the informers have synced. There is no leader election,
+			// so there is no leader check.
+			probes := newProbes()
+			informers := healthz.NewInformerSync()
+			watching := healthz.Freshness("watch", watchStaleAfter, controllerWatchActivity.lastActivity)
+			probes.readyz.Add(apiServerCheck(clientset), informers, watching)
+			probes.startupz.Add(healthz.Once(informers), healthz.Once(watching))
+			if reconciler != nil {
+				informers.Add(v1alpha1.DeploymentMonitorResource.Resource, reconciler.HasSynced)
+				monitors := monitorsCheck(reconciler)
+				probes.readyz.Add(monitors)
+				probes.startupz.Add(healthz.Once(monitors))
+			}
+			serveHealthPort("", cfg.Controller.HealthPort, probes)
+		}
+
+		fmt.Println("Watching deployments for changes... (Press Ctrl+C to stop)")
+		color := diff.ColorEnabled(os.Stdout)
+		watchDeployments(cmd.Context(), clientset, watchTarget{namespace: cfg.Controller.Namespace, labelSelector: cfg.Controller.LabelSelector}, targets,
+			watchOptions{watcher: watcherController, activity: controllerWatchActivity},
+			func(eventType watch.EventType, old, current *appsv1.Deployment) {
+				printDeploymentEvent(eventType, old, current, diff.Options{IgnoreStatus: ignoreStatus.Load()}, color)
+			})
 	} else {
 		showDeploymentStatus(clientset, namespaceLogger)
 	}
 }
 
-func getKubernetesClient() (*kubernetes.Clientset, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = os.Getenv("HOME") + "/.kube/config"
+// printDeploymentEvent logs a deployment change and prints it with the
+// changed fields. Modifications without a meaningful change, such as a new
+// resourceVersion or a status heartbeat, are skipped.
+func printDeploymentEvent(eventType watch.EventType, old, current *appsv1.Deployment, opts diff.Options, color bool) {
+	var changes diff.Result
+	if eventType == watch.Modified && old != nil {
+		changes = diff.Deployments(old, current, opts)
+		if changes.Empty() {
+			return
+		}
 	}
 
-	log.Debug("Loading kubeconfig", map[string]interface{}{
-		"kubeconfig_path": kubeconfig,
+	var desiredReplicas int32
+	if current.Spec.Replicas != nil {
+		desiredReplicas = *current.Spec.Replicas
+	}
+	log.WithNamespace(current.Namespace).WithDeployment(current.Name).Info("Deployment event detected", map[string]interface{}{
+		"event_type":       eventType,
+		"deployment_name":  current.Name,
+		"ready_replicas":   current.Status.ReadyReplicas,
+		"desired_replicas": desiredReplicas,
+		"changes":          len(changes.Changes),
 	})
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	timestamp := time.Now().Format("15:04:05")
+	fmt.Printf("[%s] %s: %s\n", timestamp, eventType, current.Name)
+	diff.Fprint(os.Stdout, changes.Changes, color)
+}
+
+func getKubernetesClient() (*kubernetes.Clientset, error) {
+	config, err := getRESTConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+		return nil, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
@@ -85,23 +173,50 @@ func getKubernetesClient() (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
-func showDeploymentStatus(clientset *kubernetes.Clientset, namespaceLogger *logger.Logger) {
-	namespaceLogger.Info("Fetching deployment status", nil)
+// getRESTConfig loads the kubeconfig of --kubeconfig, $KUBECONFIG or
+// ~/.kube/config
+func getRESTConfig() (*rest.Config, error) {
+	kubeconfig := cfg.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("HOME") + "/.kube/config"
+	}
 
-	deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	log.Debug("Loading kubeconfig", map[string]interface{}{
+		"kubeconfig_path": kubeconfig,
+	})
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
-		namespaceLogger.Error("Failed to get deployments", err, nil)
-		return
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
 	}
 
-	namespaceLogger.Info("Deployment status retrieved", map[string]interface{}{
-		"deployment_count": len(deployments.Items),
+	// Record a client span for every API server request
+	config.Wrap(tracing.WrapTransport)
+	return config, nil
+}
+
+func showDeploymentStatus(clientset *kubernetes.Clientset, namespaceLogger *logger.Logger) {
+	namespaceLogger.Info("Fetching deployment status", map[string]interface{}{
+		"chunk_size": cfg.Controller.ChunkSize,
 	})
 
 	fmt.Println("DEPLOYMENT STATUS")
 	fmt.Println("=================")
 
-	for _, deployment := range deployments.Items {
+	// List in chunks so namespaces with thousands of deployments stay responsive
+	deploymentPager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.AppsV1().Deployments(cfg.Controller.Namespace).List(context.TODO(), opts)
+	}))
+	deploymentPager.PageSize = cfg.Controller.ChunkSize
+
+	deploymentCount := 0
+	err := deploymentPager.EachListItem(context.TODO(), metav1.ListOptions{LabelSelector: cfg.Controller.LabelSelector}, func(obj runtime.Object) error {
+		deployment := obj.(*appsv1.Deployment)
+		deploymentCount++
+
 		deploymentLogger := namespaceLogger.WithDeployment(deployment.Name)
 
 		readyReplicas := deployment.Status.ReadyReplicas
@@ -129,15 +244,24 @@ func showDeploymentStatus(clientset *kubernetes.Clientset, namespaceLogger *logg
 				"desired_replicas": desiredReplicas,
 			})
 		}
+		return nil
+	})
+	if err != nil {
+		namespaceLogger.Error("Failed to get deployments", err, nil)
+		return
 	}
 
+	namespaceLogger.Info("Deployment status retrieved", map[string]interface{}{
+		"deployment_count": deploymentCount,
+	})
+
 	showRecentEvents(clientset, namespaceLogger)
 }
 
 func showRecentEvents(clientset *kubernetes.Clientset, namespaceLogger *logger.Logger) {
 	namespaceLogger.Info("Fetching recent events", nil)
 
-	events, err := clientset.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+	events, err := clientset.CoreV1().Events(cfg.Controller.Namespace).List(context.TODO(), metav1.ListOptions{
 		Limit: 10,
 	})
 	if err != nil {
@@ -175,36 +299,3 @@ func showRecentEvents(clientset *kubernetes.Clientset, namespaceLogger *logger.L
 		}
 	}
 }
-
-func watchDeployments(clientset *kubernetes.Clientset, namespaceLogger *logger.Logger) {
-	namespaceLogger.Info("Starting deployment watcher", map[string]interface{}{
-		"watch_mode": true,
-	})
-
-	fmt.Println("Watching deployments for changes... (Press Ctrl+C to stop)")
-
-	watcher, err := clientset.AppsV1().Deployments(namespace).Watch(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		namespaceLogger.Error("Failed to create deployment watcher", err, nil)
-		return
-	}
-	defer watcher.Stop()
-
-	namespaceLogger.Info("Deployment watcher started successfully", nil)
-
-	for event := range watcher.ResultChan() {
-		deployment := event.Object.(*appsv1.Deployment)
-		timestamp := time.Now().Format("15:04:05")
-
-		deploymentLogger := namespaceLogger.WithDeployment(deployment.Name)
-
-		deploymentLogger.Info("Deployment event detected", map[string]interface{}{
-			"event_type":       event.Type,
-			"deployment_name":  deployment.Name,
-			"ready_replicas":   deployment.Status.ReadyReplicas,
-			"desired_replicas": *deployment.Spec.Replicas,
-		})
-
-		fmt.Printf("[%s] %s: %s\n", timestamp, event.Type, deployment.Name)
-	}
-}
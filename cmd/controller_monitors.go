@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/healthz"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/monitor"
+)
+
+// startMonitors reconciles the DeploymentMonitor objects of all namespaces
+// until ctx is cancelled. Their alerts are logged and sent to the sinks the
+// monitors name. The reconciler is returned for the health checks.
+func startMonitors(ctx context.Context, clientset kubernetes.Interface, alerting *alerting, reloader *configReloader) (*monitor.Reconciler, error) {
+	restConfig, err := getRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	monitorClient, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create monitoring clientset: %v", err)
+	}
+
+	reconciler := monitor.NewReconciler(clientset, monitorClient, "", cfg.Controller.ChunkSize, cfg.Alerts.Interval)
+	reconciler.Changed = func(m *v1alpha1.DeploymentMonitor, alert alerts.Alert) {
+		alert = monitorAlert(m, alert)
+		logAlert(alert)
+		if unknown := alerting.dispatcher.NotifySinks(alert, m.Spec.Notifications); len(unknown) > 0 {
+			log.WithNamespace(m.Namespace).Warn("DeploymentMonitor names unknown notification sinks", map[string]interface{}{
+				"monitor": m.Name,
+				"sinks":   unknown,
+			})
+		}
+	}
+	reconciler.Evaluated = recordMonitorEvaluation
+	reconciler.SetCrossNamespace(cfg.Controller.CrossNamespaceMonitors)
+	reloader.handle(func(c *config.Config) error {
+		reconciler.SetInterval(c.Alerts.Interval)
+		return nil
+	}, "alerts.interval")
+	reloader.handle(func(c *config.Config) error {
+		reconciler.SetCrossNamespace(c.Controller.CrossNamespaceMonitors)
+		return nil
+	}, "controller.crossNamespaceMonitors")
+
+	// A failure shows in the monitors health check, which keeps the
+	// controller from becoming ready
+	go func() {
+		if err := reconciler.Run(ctx); err != nil {
+			log.Error("DeploymentMonitor reconciler stopped; is the CRD installed?", err, nil)
+		}
+	}()
+	log.Info("Reconciling DeploymentMonitor objects", map[string]interface{}{
+		"resource": v1alpha1.DeploymentMonitorResource.String(),
+	})
+	return reconciler, nil
+}
+
+// monitorsCheck passes while the reconciler runs and has listed the
+// monitors
+func monitorsCheck(reconciler *monitor.Reconciler) healthz.Checker {
+	return healthz.NamedCheck("monitors", func(context.Context) error {
+		if err := reconciler.Running(); err != nil {
+			return err
+		}
+		if !reconciler.HasSynced() {
+			return fmt.Errorf("%s not listed yet", v1alpha1.DeploymentMonitorResource.GroupResource())
+		}
+		return nil
+	})
+}
+
+// monitorAlert labels an alert with the monitor that raised it
+func monitorAlert(m *v1alpha1.DeploymentMonitor, alert alerts.Alert) alerts.Alert {
+	labels := make(map[string]string, len(alert.Labels)+1)
+	for key, value := range alert.Labels {
+		labels[key] = value
+	}
+	labels["monitor"] = m.Namespace + "/" + m.Name
+	alert.Labels = labels
+	return alert
+}
+
+// recordMonitorEvaluation logs and counts an evaluation of a monitor
+func recordMonitorEvaluation(m *v1alpha1.DeploymentMonitor, status *v1alpha1.DeploymentMonitorStatus, err error) {
+	monitorLogger := log.WithNamespace(m.Namespace)
+	if err != nil {
+		metrics.MonitorEvaluations.WithLabelValues("failure").Inc()
+		monitorLogger.Error("Failed to evaluate DeploymentMonitor", err, map[string]interface{}{
+			"monitor": m.Name,
+		})
+		return
+	}
+	metrics.MonitorEvaluations.WithLabelValues("success").Inc()
+	monitorLogger.Debug("DeploymentMonitor evaluated", map[string]interface{}{
+		"monitor":             m.Name,
+		"matched_deployments": status.MatchedDeployments,
+		"healthy_deployments": status.HealthyDeployments,
+		"firing_alerts":       len(status.FiringAlerts),
+	})
+}
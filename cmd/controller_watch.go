@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/pager"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/logger"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+)
+
+// watchStaleAfter is how long the watch may go without an event or bookmark
+// before readyz fails; the API server sends bookmarks about once a minute
+const watchStaleAfter = 5 * time.Minute
+
+// backoffResetAfter is how long a watch must run before a failure is retried
+// with the initial delay again
+const backoffResetAfter = 2 * time.Minute
+
+// Reasons for restarting the watch, the reason label of
+// k8s_controller_watch_restarts_total
+const (
+	// restartReconnect: the API server closed the watch, which it does every
+	// few minutes; it resumes from the last resourceVersion
+	restartReconnect = "reconnect"
+	// restartExpired: the resourceVersion is too old (410 Gone); relist
+	restartExpired = "expired"
+	// restartError: the watch failed; relist after a backoff
+	restartError = "error"
+	// restartTarget: the namespace or selector changed in a config reload
+	restartTarget = "target"
+)
+
+// Watchers, the watcher label of k8s_controller_watch_restarts_total
+const (
+	// watcherController is the deployment watch of controller --watch
+	watcherController = "controller"
+	// watcherAPI is a watch streamed to a client of /api/v1/watch/deployments
+	watcherAPI = "api"
+)
+
+// watchActivity records when a watch last started or delivered an event
+type watchActivity struct {
+	last atomic.Pointer[time.Time]
+}
+
+// mark records that the watch is alive
+func (a *watchActivity) mark() {
+	if a == nil {
+		return
+	}
+	now := time.Now()
+	a.last.Store(&now)
+}
+
+// lastActivity returns the time of the last watch activity, or the zero time
+// before the first watch started
+func (a *watchActivity) lastActivity() time.Time {
+	if t := a.last.Load(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// controllerWatchActivity is the activity of the controller's watch, which
+// readyz checks
+var controllerWatchActivity = &watchActivity{}
+
+// watchOptions identify a watch and receive its failures
+type watchOptions struct {
+	// watcher is the watcher label of k8s_controller_watch_restarts_total
+	watcher string
+	// activity, if set, records when the watch was last alive
+	activity *watchActivity
+	// failed, if set, is called with every error the watch relists after.
+	// The watch ends when it returns false.
+	failed func(err error) bool
+}
+
+// watchTarget selects the deployments the controller watches
+type watchTarget struct {
+	namespace     string
+	labelSelector string
+}
+
+// newWatchBackoff waits 1s, 2s, 4s, ... up to a minute, each ±50%, so that
+// many controllers do not retry in lockstep after an API server outage
+func newWatchBackoff() *wait.Backoff {
+	return &wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      time.Minute,
+	}
+}
+
+// deploymentHandler receives the changes of a watch. old is the previous
+// state of the deployment, nil for ADDED and when it was not seen before.
+type deploymentHandler func(eventType watch.EventType, old, current *appsv1.Deployment)
+
+// watchDeployments lists and then watches the deployments of target until ctx
+// is cancelled, passing every change to handle. The watch resumes from the
+// last resourceVersion when the API server closes it, relists when that
+// resourceVersion has expired, retries failures with backoff and restarts
+// when a new target arrives from a configuration reload.
+func watchDeployments(ctx context.Context, clientset *kubernetes.Clientset, target watchTarget, targets <-chan watchTarget, opts watchOptions, handle deploymentHandler) {
+	w := newDeploymentWatch(clientset, target, opts, handle)
+	backoff := newWatchBackoff()
+	for ctx.Err() == nil {
+		started := time.Now()
+		reason, err := w.listAndWatch(ctx, targets)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > backoffResetAfter {
+			backoff = newWatchBackoff()
+		}
+		metrics.WatchRestarts.WithLabelValues(opts.watcher, "deployments", reason).Inc()
+
+		switch reason {
+		case restartTarget:
+			w.logger.Info("Watch target changed; restarting deployment watcher", map[string]interface{}{
+				"new_namespace":      w.next.namespace,
+				"new_label_selector": w.next.labelSelector,
+			})
+			w.retarget(w.next)
+			backoff = newWatchBackoff()
+
+		case restartExpired:
+			w.logger.Info("Deployment watch expired; relisting", map[string]interface{}{
+				"reason": reason,
+				"error":  err.Error(),
+			})
+
+		default:
+			if opts.failed != nil && !opts.failed(err) {
+				return
+			}
+			delay := backoff.Step()
+			w.logger.Error("Deployment watch failed; relisting after backoff", err, map[string]interface{}{
+				"reason":   reason,
+				"retry_in": delay.Round(time.Millisecond).String(),
+			})
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case next := <-targets:
+				timer.Stop()
+				w.retarget(next)
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// deploymentWatch follows the deployments of one target
+type deploymentWatch struct {
+	clientset *kubernetes.Clientset
+	target    watchTarget
+	opts      watchOptions
+	handle    deploymentHandler
+	logger    *logger.Logger
+	// next is the target that ended the last watch
+	next watchTarget
+	// known is the last state seen of each deployment, so that a relist
+	// reports only what changed while the watch was down
+	known map[string]*appsv1.Deployment
+}
+
+func newDeploymentWatch(clientset *kubernetes.Clientset, target watchTarget, opts watchOptions, handle deploymentHandler) *deploymentWatch {
+	w := &deploymentWatch{clientset: clientset, opts: opts, handle: handle}
+	w.retarget(target)
+	return w
+}
+
+// retarget switches to target; its deployments are reported as added
+func (w *deploymentWatch) retarget(target watchTarget) {
+	w.target = target
+	w.logger = log.WithNamespace(target.namespace)
+	w.known = nil
+}
+
+// listAndWatch lists the deployments, then watches from the resourceVersion
+// of the list until the watch can no longer resume. It returns why.
+func (w *deploymentWatch) listAndWatch(ctx context.Context, targets <-chan watchTarget) (string, error) {
+	resourceVersion, err := w.list(ctx)
+	if err != nil {
+		return restartError, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	w.logger.Info("Starting deployment watcher", map[string]interface{}{
+		"watch_mode":       true,
+		"label_selector":   w.target.labelSelector,
+		"resource_version": resourceVersion,
+	})
+
+	// Called for the first watch and every time the API server closed it
+	established := false
+	lw := &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			if established {
+				metrics.WatchRestarts.WithLabelValues(w.opts.watcher, "deployments", restartReconnect).Inc()
+				w.logger.Debug("Resuming deployment watch", map[string]interface{}{
+					"reason":           restartReconnect,
+					"resource_version": options.ResourceVersion,
+				})
+			}
+			established = true
+
+			options.LabelSelector = w.target.labelSelector
+			watcher, err := w.clientset.AppsV1().Deployments(w.target.namespace).Watch(ctx, options)
+			if err != nil {
+				return nil, err
+			}
+			w.opts.activity.mark()
+			// Bookmarks do not reach the consumer of a RetryWatcher, but
+			// they show the watch is alive
+			return watch.Filter(watcher, func(event watch.Event) (watch.Event, bool) {
+				w.opts.activity.mark()
+				return event, true
+			}), nil
+		},
+	}
+
+	watcher, err := watchtools.NewRetryWatcherWithContext(ctx, resourceVersion, lw)
+	if err != nil {
+		return restartError, err
+	}
+	defer watcher.Stop()
+
+	w.logger.Info("Deployment watcher started successfully", nil)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return restartError, ctx.Err()
+
+		case next := <-targets:
+			w.next = next
+			return restartTarget, nil
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return restartError, fmt.Errorf("watch closed")
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				deployment, ok := event.Object.(*appsv1.Deployment)
+				if !ok {
+					w.logger.Warn("Unexpected object in deployment watch", map[string]interface{}{
+						"event_type":  event.Type,
+						"object_type": fmt.Sprintf("%T", event.Object),
+					})
+					continue
+				}
+				old := w.known[deployment.Name]
+				if event.Type == watch.Added {
+					old = nil
+				}
+				w.report(event.Type, old, deployment)
+
+			case watch.Error:
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return restartExpired, err
+				}
+				return restartError, err
+			}
+		}
+	}
+}
+
+// list fetches the deployments in chunks and reports the changes since the
+// previous list. The first list reports every deployment as added, like a
+// watch without a resourceVersion does.
+func (w *deploymentWatch) list(ctx context.Context) (string, error) {
+	deploymentPager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return w.clientset.AppsV1().Deployments(w.target.namespace).List(ctx, opts)
+	}))
+	deploymentPager.PageSize = cfg.Controller.ChunkSize
+
+	list, _, err := deploymentPager.List(ctx, metav1.ListOptions{LabelSelector: w.target.labelSelector})
+	if err != nil {
+		return "", err
+	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return "", err
+	}
+
+	previous := w.known
+	w.known = make(map[string]*appsv1.Deployment)
+	err = meta.EachListItem(list, func(obj runtime.Object) error {
+		deployment, ok := obj.(*appsv1.Deployment)
+		if !ok {
+			return fmt.Errorf("unexpected object %T in deployment list", obj)
+		}
+		old, seen := previous[deployment.Name]
+		delete(previous, deployment.Name)
+		switch {
+		case !seen:
+			w.report(watch.Added, nil, deployment)
+		case old.ResourceVersion != deployment.ResourceVersion:
+			w.report(watch.Modified, old, deployment)
+		default:
+			w.known[deployment.Name] = deployment
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, deployment := range previous {
+		w.report(watch.Deleted, deployment, deployment)
+	}
+
+	w.opts.activity.mark()
+	return listMeta.GetResourceVersion(), nil
+}
+
+// report remembers the new state of a deployment and passes the change on
+func (w *deploymentWatch) report(eventType watch.EventType, old, deployment *appsv1.Deployment) {
+	if eventType == watch.Deleted {
+		delete(w.known, deployment.Name)
+	} else {
+		w.known[deployment.Name] = deployment
+	}
+	w.handle(eventType, old, deployment)
+}
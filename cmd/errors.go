@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/ratelimit"
+)
+
+// internalErrorMessage replaces the text of unexpected errors when they are not exposed
+const internalErrorMessage = "internal error; the server log has details"
+
+// APIError is the error in the Response envelope. Reason uses the Kubernetes
+// status reasons (NotFound, Forbidden, Timeout, ...) so clients can branch on it.
+type APIError struct {
+	Code      int           `json:"code"`
+	Reason    string        `json:"reason"`
+	Message   string        `json:"message"`
+	Details   *ErrorDetails `json:"details,omitempty"`
+	Retryable bool          `json:"retryable"`
+
+	// public is set when Message was written by this server rather than taken
+	// from the Kubernetes client, so it is safe to return in production
+	public bool
+}
+
+// ErrorDetails identifies the object an error is about and how to recover
+type ErrorDetails struct {
+	Group             string   `json:"group,omitempty"`
+	Kind              string   `json:"kind,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Namespace         string   `json:"namespace,omitempty"`
+	RetryAfterSeconds int      `json:"retry_after_seconds,omitempty"`
+	Causes            []string `json:"causes,omitempty"`
+	// TraceID finds the request in traces and logs
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// NotFoundError reports that a requested Kubernetes object does not exist
+type NotFoundError struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found in namespace %q", e.Kind, e.Name, e.Namespace)
+}
+
+// ForbiddenError reports that the caller may not perform an action
+type ForbiddenError struct {
+	User       string
+	Attributes auth.Attributes
+	Reason     string
+}
+
+func (e *ForbiddenError) Error() string {
+	msg := fmt.Sprintf("user %q cannot %s", e.User, e.Attributes)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// newAPIError classifies err. Typed errors and Kubernetes API errors get their
+// own status code; anything else gets fallback. The messages of typed errors
+// and of untyped errors with a 4xx fallback, which handlers reject requests
+// with, are public.
+func newAPIError(err error, fallback int) *APIError {
+	apiErr := &APIError{Code: fallback, Message: err.Error(), public: true}
+
+	var (
+		notFound    *NotFoundError
+		forbidden   *ForbiddenError
+		rejection   *ratelimit.Rejection
+		unavailable *auth.UnavailableError
+		status      apierrors.APIStatus
+	)
+	switch {
+	case errors.As(err, &notFound):
+		apiErr.Code = fasthttp.StatusNotFound
+		apiErr.Details = &ErrorDetails{Kind: notFound.Kind, Name: notFound.Name, Namespace: notFound.Namespace}
+	case errors.As(err, &forbidden):
+		apiErr.Code = fasthttp.StatusForbidden
+		apiErr.Details = &ErrorDetails{
+			Group:     forbidden.Attributes.Group,
+			Kind:      forbidden.Attributes.Resource,
+			Name:      forbidden.Attributes.Name,
+			Namespace: forbidden.Attributes.Namespace,
+		}
+	case errors.As(err, &unavailable):
+		// The wrapped error comes from the API server client and stays in the logs
+		apiErr.Code = fasthttp.StatusServiceUnavailable
+		apiErr.Message = unavailable.Review + " is unavailable; retry later"
+	case errors.As(err, &rejection):
+		apiErr.Code = fasthttp.StatusTooManyRequests
+		apiErr.Details = &ErrorDetails{RetryAfterSeconds: int(rejection.RetryAfter.Seconds() + 0.999)}
+	case errors.Is(err, context.DeadlineExceeded):
+		apiErr.Code = fasthttp.StatusGatewayTimeout
+		apiErr.public = false
+	case errors.As(err, &status):
+		apiErr.Code = kubernetesStatusCode(err, fallback)
+		apiErr.public = false
+		if details := status.Status().Details; details != nil {
+			apiErr.Details = &ErrorDetails{
+				Group:             details.Group,
+				Kind:              details.Kind,
+				Name:              details.Name,
+				RetryAfterSeconds: int(details.RetryAfterSeconds),
+			}
+			for _, cause := range details.Causes {
+				apiErr.Details.Causes = append(apiErr.Details.Causes, cause.Message)
+			}
+		}
+	default:
+		apiErr.public = fallback >= 400 && fallback < 500
+	}
+
+	apiErr.Reason = statusReason(apiErr.Code)
+	switch apiErr.Code {
+	case fasthttp.StatusTooManyRequests, fasthttp.StatusServiceUnavailable, fasthttp.StatusGatewayTimeout:
+		apiErr.Retryable = true
+	}
+	return apiErr
+}
+
+// kubernetesStatusCode maps an error from the Kubernetes API to the status
+// this server answers with
+func kubernetesStatusCode(err error, fallback int) int {
+	switch {
+	case apierrors.IsNotFound(err):
+		return fasthttp.StatusNotFound
+	case apierrors.IsForbidden(err):
+		return fasthttp.StatusForbidden
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return fasthttp.StatusGatewayTimeout
+	case apierrors.IsTooManyRequests(err):
+		return fasthttp.StatusTooManyRequests
+	case apierrors.IsResourceExpired(err), apierrors.IsGone(err):
+		// An expired continue token; the client restarts from the first page
+		return fasthttp.StatusGone
+	case apierrors.IsServiceUnavailable(err):
+		return fasthttp.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+// statusReason returns the Kubernetes status reason for an HTTP status code
+func statusReason(code int) string {
+	switch code {
+	case fasthttp.StatusBadRequest:
+		return string(metav1.StatusReasonBadRequest)
+	case fasthttp.StatusUnauthorized:
+		return string(metav1.StatusReasonUnauthorized)
+	case fasthttp.StatusForbidden:
+		return string(metav1.StatusReasonForbidden)
+	case fasthttp.StatusNotFound:
+		return string(metav1.StatusReasonNotFound)
+	case fasthttp.StatusMethodNotAllowed:
+		return string(metav1.StatusReasonMethodNotAllowed)
+	case fasthttp.StatusGone:
+		return string(metav1.StatusReasonExpired)
+	case fasthttp.StatusTooManyRequests:
+		return string(metav1.StatusReasonTooManyRequests)
+	case fasthttp.StatusServiceUnavailable:
+		return string(metav1.StatusReasonServiceUnavailable)
+	case fasthttp.StatusGatewayTimeout:
+		return string(metav1.StatusReasonTimeout)
+	case fasthttp.StatusInternalServerError:
+		return string(metav1.StatusReasonInternalError)
+	}
+	return strings.ReplaceAll(http.StatusText(code), " ", "")
+}
+
+// errorStatusCode returns the HTTP status for a typed error, or fallback
+func errorStatusCode(err error, fallback int) int {
+	if err == nil {
+		return fallback
+	}
+	return newAPIError(err, fallback).Code
+}
+
+// sanitize replaces messages that are not public with one derived from the
+// status code unless internal errors are exposed. They are replaced by default
+// in production, where errors from the Kubernetes client may carry the API
+// server's address or other internals. The trace ID lets operators find the
+// full error in the logs.
+func (e *APIError) sanitize(ctx context.Context) {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		if e.Details == nil {
+			e.Details = &ErrorDetails{}
+		}
+		e.Details.TraceID = spanContext.TraceID().String()
+	}
+	e.redact()
+}
+
+// redact replaces a message that is not public, and the causes that came with
+// it, unless internal errors are exposed
+func (e *APIError) redact() {
+	if cfg.Server.ExposeInternalErrors || e.public {
+		return
+	}
+	e.Message = publicMessage(e.Code)
+	if e.Details != nil {
+		e.Details.Causes = nil
+	}
+}
+
+// publicMessage describes a status code without details of the cause
+func publicMessage(code int) string {
+	switch code {
+	case fasthttp.StatusForbidden:
+		return "the Kubernetes API denied the server access; the server log has details"
+	case fasthttp.StatusNotFound:
+		return "not found"
+	case fasthttp.StatusGone:
+		return "the continue token has expired; list again from the first page"
+	case fasthttp.StatusTooManyRequests:
+		return "the Kubernetes API is throttling requests; retry later"
+	case fasthttp.StatusServiceUnavailable:
+		return "the Kubernetes API is unavailable; retry later"
+	case fasthttp.StatusGatewayTimeout:
+		return "timed out waiting for the Kubernetes API; retry later"
+	case fasthttp.StatusInternalServerError:
+		return internalErrorMessage
+	}
+	return strings.ToLower(http.StatusText(code)) + "; the server log has details"
+}
+
+// publicErrorMessage returns the text of err that may be returned to clients
+func publicErrorMessage(err error, fallback int) string {
+	apiErr := newAPIError(err, fallback)
+	apiErr.redact()
+	return apiErr.Message
+}
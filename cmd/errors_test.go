@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+)
+
+// apiServer is the internal address that must not reach clients
+const apiServer = "https://10.0.0.1:6443"
+
+func TestAPIErrorSanitize(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name      string
+		err       error
+		fallback  int
+		code      int
+		reason    string
+		public    string
+		retryable bool
+	}{
+		{
+			name:      "client timeout",
+			err:       fmt.Errorf(`Get "%s/apis/apps/v1/deployments": %w`, apiServer, context.DeadlineExceeded),
+			fallback:  fasthttp.StatusInternalServerError,
+			code:      fasthttp.StatusGatewayTimeout,
+			reason:    "Timeout",
+			retryable: true,
+		},
+		{
+			name:     "API server forbidden",
+			err:      apierrors.NewForbidden(deployments, "", errors.New(`User "system:serviceaccount:ops:controller" cannot list resource "deployments" at `+apiServer)),
+			fallback: fasthttp.StatusInternalServerError,
+			code:     fasthttp.StatusForbidden,
+			reason:   "Forbidden",
+		},
+		{
+			name:      "API server unavailable",
+			err:       apierrors.NewServiceUnavailable("etcd at " + apiServer + " is unavailable"),
+			fallback:  fasthttp.StatusInternalServerError,
+			code:      fasthttp.StatusServiceUnavailable,
+			reason:    "ServiceUnavailable",
+			retryable: true,
+		},
+		{
+			name:      "API server throttling",
+			err:       apierrors.NewTooManyRequests("throttled by "+apiServer, 2),
+			fallback:  fasthttp.StatusInternalServerError,
+			code:      fasthttp.StatusTooManyRequests,
+			reason:    "TooManyRequests",
+			retryable: true,
+		},
+		{
+			name:     "untyped server error",
+			err:      errors.New("dial tcp " + strings.TrimPrefix(apiServer, "https://") + ": connection refused"),
+			fallback: fasthttp.StatusInternalServerError,
+			code:     fasthttp.StatusInternalServerError,
+			reason:   "InternalError",
+			public:   internalErrorMessage,
+		},
+		{
+			name:      "review unavailable",
+			err:       &auth.UnavailableError{Review: "subject access review", Err: fmt.Errorf(`Post "%s": %w`, apiServer, context.DeadlineExceeded)},
+			fallback:  fasthttp.StatusServiceUnavailable,
+			code:      fasthttp.StatusServiceUnavailable,
+			reason:    "ServiceUnavailable",
+			public:    "subject access review is unavailable; retry later",
+			retryable: true,
+		},
+		{
+			name:     "bad request",
+			err:      errors.New(`limit must be a positive integer: "x"`),
+			fallback: fasthttp.StatusBadRequest,
+			code:     fasthttp.StatusBadRequest,
+			reason:   "BadRequest",
+			public:   `limit must be a positive integer: "x"`,
+		},
+		{
+			name:     "typed not found",
+			err:      &NotFoundError{Kind: "Deployment", Namespace: "shop", Name: "web"},
+			fallback: fasthttp.StatusInternalServerError,
+			code:     fasthttp.StatusNotFound,
+			reason:   "NotFound",
+			public:   `Deployment "web" not found in namespace "shop"`,
+		},
+	}
+
+	expose := cfg.Server.ExposeInternalErrors
+	defer func() { cfg.Server.ExposeInternalErrors = expose }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Server.ExposeInternalErrors = false
+			apiErr := newAPIError(tt.err, tt.fallback)
+			apiErr.sanitize(context.Background())
+
+			if apiErr.Code != tt.code || apiErr.Reason != tt.reason || apiErr.Retryable != tt.retryable {
+				t.Errorf("got %d %s retryable=%v, want %d %s retryable=%v", apiErr.Code, apiErr.Reason, apiErr.Retryable, tt.code, tt.reason, tt.retryable)
+			}
+			if strings.Contains(apiErr.Message, "10.0.0.1") || strings.Contains(apiErr.Message, "serviceaccount") {
+				t.Errorf("message leaks internals: %q", apiErr.Message)
+			}
+			if tt.public != "" && apiErr.Message != tt.public {
+				t.Errorf("message = %q, want %q", apiErr.Message, tt.public)
+			}
+
+			cfg.Server.ExposeInternalErrors = true
+			exposed := newAPIError(tt.err, tt.fallback)
+			exposed.sanitize(context.Background())
+			if _, review := tt.err.(*auth.UnavailableError); !review && exposed.Message != tt.err.Error() {
+				t.Errorf("exposed message = %q, want %q", exposed.Message, tt.err.Error())
+			}
+		})
+	}
+}
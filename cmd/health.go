@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/healthz"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// probeTimeout bounds each health check
+const probeTimeout = 5 * time.Second
+
+// probes are the check sets behind /livez, /readyz and /startupz. The kubelet
+// restarts the container when livez fails, stops sending traffic while readyz
+// fails, and runs neither until startupz has passed.
+type probes struct {
+	livez    *healthz.Set
+	readyz   *healthz.Set
+	startupz *healthz.Set
+}
+
+// newProbes creates the sets with the ping check; commands add the checks of
+// the components they run
+func newProbes() *probes {
+	p := &probes{
+		livez:    healthz.NewSet("livez", healthz.Ping),
+		readyz:   healthz.NewSet("readyz", healthz.Ping),
+		startupz: healthz.NewSet("startupz", healthz.Ping),
+	}
+	for probe, set := range map[string]*healthz.Set{"livez": p.livez, "readyz": p.readyz, "startupz": p.startupz} {
+		set.Failed = func(name string, err error) {
+			log.Warn("Health check failed", map[string]interface{}{
+				"probe": probe,
+				"check": name,
+				"error": err.Error(),
+			})
+		}
+	}
+	return p
+}
+
+// register serves the probes on r
+func (p *probes) register(r *router.Router) {
+	r.GET("/livez", cacheControl(cacheNever)(p.livez.Handler(probeTimeout)))
+	r.GET("/readyz", cacheControl(cacheNever)(p.readyz.Handler(probeTimeout)))
+	r.GET("/startupz", cacheControl(cacheNever)(p.startupz.Handler(probeTimeout)))
+}
+
+// apiServerCheck passes when the API server answers. It reads /version, which
+// every client may read, so it needs no RBAC permission.
+func apiServerCheck(clientset kubernetes.Interface) healthz.Checker {
+	return healthz.NamedCheck("apiserver", func(ctx context.Context) error {
+		_, err := clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+		return err
+	})
+}
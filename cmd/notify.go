@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/notify"
+)
+
+var (
+	notifySinks     []string
+	notifyAlert     alerts.Alert
+	notifyTimeout   time.Duration
+	notifyNoRetries bool
+)
+
+// notifyCmd sends a synthetic alert to the notification sinks
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Send a test alert to the notification sinks",
+	Long: `Send a synthetic alert to the sinks of --alert-notifications and report the
+result of each delivery. The alert is routed like a real one, unless --sink
+names the sinks to send to.
+
+Examples:
+  # Send a critical alert in namespace web to the sinks routing it
+  k8s-controller-tutorial notify --alert-notifications notifications.yaml -n web --severity critical
+
+  # Send a resolved alert to one sink, without retries
+  k8s-controller-tutorial notify --alert-notifications notifications.yaml --sink team-web --state resolved --no-retries`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if cfg.Alerts.NotificationsFile == "" {
+			return fmt.Errorf("--alert-notifications is required")
+		}
+		sinks, err := notify.Load(cfg.Alerts.NotificationsFile)
+		if err != nil {
+			return err
+		}
+		if sinks, err = selectSinks(sinks, notifySinks); err != nil {
+			return err
+		}
+		if notifyNoRetries {
+			for i := range sinks {
+				sinks[i].Retry.Attempts = 1
+			}
+		}
+
+		alert, err := testAlert()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), notifyTimeout)
+		defer cancel()
+		dispatcher := newDispatcher(ctx, cfg.Alerts.DeadLetterFile)
+
+		var mu sync.Mutex
+		var failed int
+		out := cmd.OutOrStdout()
+		sent, deadLettered := dispatcher.Sent, dispatcher.DeadLettered
+		dispatcher.Sent = func(sink string, alert alerts.Alert, attempts int) {
+			sent(sink, alert, attempts)
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(out, "%s: sent after %d attempt(s)\n", sink, attempts)
+		}
+		dispatcher.DeadLettered = func(letter notify.DeadLetter) {
+			deadLettered(letter)
+			mu.Lock()
+			defer mu.Unlock()
+			failed++
+			fmt.Fprintf(out, "%s: failed after %d attempt(s): %s\n", letter.Sink, letter.Attempts, letter.Error)
+		}
+
+		if err := dispatcher.SetSinks(sinks); err != nil {
+			return err
+		}
+		dispatcher.Notify(alert)
+		if err := dispatcher.Wait(ctx); err != nil {
+			return fmt.Errorf("notifications not delivered within %s", notifyTimeout)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d notification(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+// selectSinks returns the sinks named in names, which then receive every
+// alert; without names it returns all sinks
+func selectSinks(sinks []notify.SinkConfig, names []string) ([]notify.SinkConfig, error) {
+	if len(names) == 0 {
+		return sinks, nil
+	}
+	selected := make([]notify.SinkConfig, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, s := range sinks {
+			if s.Name == name {
+				s.Route = notify.Route{}
+				selected = append(selected, s)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no sink named %q in %s", name, cfg.Alerts.NotificationsFile)
+		}
+	}
+	return selected, nil
+}
+
+// testAlert completes the alert of the flags
+func testAlert() (alerts.Alert, error) {
+	alert := notifyAlert
+	known := false
+	for _, severity := range alerts.Severities {
+		known = known || severity == alert.Severity
+	}
+	if !known {
+		return alert, fmt.Errorf("unknown severity %q (expected one of %v)", alert.Severity, alerts.Severities)
+	}
+	now := time.Now().UTC()
+	alert.ActiveAt = now.Add(-time.Minute)
+	switch alert.State {
+	case alerts.StateFiring:
+		alert.FiredAt = &now
+	case alerts.StateResolved:
+		alert.FiredAt = &alert.ActiveAt
+		alert.ResolvedAt = &now
+	default:
+		return alert, fmt.Errorf("unknown state %q (expected %s or %s)", alert.State, alerts.StateFiring, alerts.StateResolved)
+	}
+	return alert, nil
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.Flags().StringSliceVar(&notifySinks, "sink", nil, "Send only to these sinks, ignoring their routes")
+	notifyCmd.Flags().StringVar(&notifyAlert.Rule, "rule", "test-notification", "Rule of the test alert")
+	notifyCmd.Flags().StringVar(&notifyAlert.State, "state", alerts.StateFiring, "State of the test alert: firing or resolved")
+	notifyCmd.Flags().StringVar(&notifyAlert.Severity, "severity", alerts.SeverityWarning, "Severity of the test alert")
+	notifyCmd.Flags().StringVarP(&notifyAlert.Namespace, "namespace", "n", "default", "Namespace of the test alert")
+	notifyCmd.Flags().StringVar(&notifyAlert.Kind, "kind", "Deployment", "Object kind of the test alert")
+	notifyCmd.Flags().StringVar(&notifyAlert.Name, "name", "test", "Object name of the test alert")
+	notifyCmd.Flags().StringVar(&notifyAlert.Message, "message", "This is a test notification", "Message of the test alert")
+	notifyCmd.Flags().DurationVar(&notifyTimeout, "timeout", 2*time.Minute, "How long to wait for all deliveries, including retries")
+	notifyCmd.Flags().BoolVar(&notifyNoRetries, "no-retries", false, "Try every sink once")
+}
@@ -4,11 +4,19 @@ Copyright © 2025 Michael Vaynagiy (Michaelcode2)
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/tracing"
 )
 
+var tracingShutdown tracing.ShutdownFunc
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "k8s-controller-tutorial",
@@ -43,12 +51,51 @@ Examples:
 
   # Get help for server command
   k8s-controller-tutorial server --help`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := cfg.Validate(); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if err := log.SetLevel(cfg.Log.Level); err != nil {
+			return err
+		}
+		if err := log.SetRedaction(cfg.Log.Redaction); err != nil {
+			return err
+		}
+		if err := log.SetSampling(cfg.Log.Sampling); err != nil {
+			return err
+		}
+
+		shutdown, err := tracing.Setup(context.Background(), cfg.Tracing)
+		if err != nil {
+			return err
+		}
+		tracingShutdown = shutdown
+		logConfigSource()
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// The config file and environment are applied before flags are parsed,
+	// so flags override them
+	err := loadConfig(os.Args[1:])
+	if err == nil {
+		startupConfig = cfg.Clone()
+		err = rootCmd.Execute()
+	} else {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	if tracingShutdown != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if shutdownErr := tracingShutdown(ctx); shutdownErr != nil {
+			log.Error("Failed to flush traces", shutdownErr, nil)
+		}
+		cancel()
+	}
+	log.Close()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -59,7 +106,36 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.k8s-controller-tutorial.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file in YAML, JSON or TOML (default $"+config.EnvConfigFile+" or $HOME/"+defaultConfigFile+" if it exists)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Kubeconfig, "kubeconfig", cfg.Kubeconfig, "Kubeconfig file (default $KUBECONFIG or $HOME/.kube/config)")
+
+	rootCmd.PersistentFlags().StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "Minimum log level: debug, info, warn or error (reloadable)")
+
+	// Log sampling keeps noisy watch loops from flooding the log pipeline
+	rootCmd.PersistentFlags().IntVar(&cfg.Log.Sampling.Burst, "log-sample-burst", cfg.Log.Sampling.Burst, "Maximum identical log messages per sample period (0 disables)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.Log.Sampling.Period, "log-sample-period", cfg.Log.Sampling.Period, "Period for per-message burst sampling")
+	rootCmd.PersistentFlags().DurationVar(&cfg.Log.Sampling.DedupWindow, "log-dedup-window", cfg.Log.Sampling.DedupWindow, "Collapse identical log messages within this window into \"repeated N times\" (0 disables)")
+	rootCmd.PersistentFlags().StringToIntVar(&cfg.Log.Sampling.LevelCaps, "log-level-cap", cfg.Log.Sampling.LevelCaps, "Maximum log messages per second per level, e.g. debug=50,info=200")
+	rootCmd.PersistentFlags().DurationVar(&cfg.Log.Sampling.ReportInterval, "log-drop-report-interval", cfg.Log.Sampling.ReportInterval, "How often to report messages dropped by sampling (0 disables)")
+
+	// Redaction masks secrets in log messages and fields (on by default when ENV=prod)
+	rootCmd.PersistentFlags().BoolVar(&cfg.Log.Redaction.Enabled, "log-redact", cfg.Log.Redaction.Enabled, "Mask sensitive data in log messages and fields")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Log.Redaction.KeyPatterns, "log-redact-keys", cfg.Log.Redaction.KeyPatterns, "Glob patterns of field names whose values are masked")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.Log.Redaction.ValuePatterns, "log-redact-values", cfg.Log.Redaction.ValuePatterns, "Regular expressions masked inside values (a group named \"secret\" limits the mask to that group)")
+
+	// Alerting rules evaluated by the server and by the controller in watch mode
+	rootCmd.PersistentFlags().StringVar(&cfg.Alerts.RulesFile, "alert-rules", cfg.Alerts.RulesFile, "YAML file of alerting rules (reloadable; empty disables alerting)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Alerts.Namespace, "alert-namespace", cfg.Alerts.Namespace, "Namespace evaluated by the alerting rules (default all namespaces)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.Alerts.Interval, "alert-interval", cfg.Alerts.Interval, "How often the alerting rules are evaluated (reloadable)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Alerts.NotificationsFile, "alert-notifications", cfg.Alerts.NotificationsFile, "YAML file of notification sinks for alerts (reloadable)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Alerts.DeadLetterFile, "alert-dead-letter-file", cfg.Alerts.DeadLetterFile, "File that undeliverable notifications are appended to as JSON lines")
+
+	// OpenTelemetry tracing for HTTP handlers and Kubernetes API calls
+	rootCmd.PersistentFlags().StringVar(&cfg.Tracing.Exporter, "trace-exporter", cfg.Tracing.Exporter, "Trace exporter: none, otlp, stdout or file")
+	rootCmd.PersistentFlags().StringVar(&cfg.Tracing.Endpoint, "otlp-endpoint", cfg.Tracing.Endpoint, "OTLP/HTTP collector address, e.g. localhost:4318 (defaults to OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Tracing.Insecure, "otlp-insecure", cfg.Tracing.Insecure, "Use plain HTTP for the OTLP exporter")
+	rootCmd.PersistentFlags().StringVar(&cfg.Tracing.FilePath, "trace-file", cfg.Tracing.FilePath, "Output file for the file trace exporter")
+	rootCmd.PersistentFlags().Float64Var(&cfg.Tracing.SampleRatio, "trace-sample-ratio", cfg.Tracing.SampleRatio, "Fraction of new traces to record")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -10,16 +12,25 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/valyala/fasthttp"
-	corev1 "k8s.io/api/core/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
-)
 
-var (
-	serverPort int
-	serverHost string
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/cors"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/healthz"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/tracing"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/version"
 )
 
+// requestContextKey is the fasthttp user value holding the traced request context
+const requestContextKey = "request_context"
+
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
 	Use:   "server",
@@ -32,16 +43,40 @@ events, and real-time monitoring.`,
 
 func init() {
 	rootCmd.AddCommand(serverCmd)
-	serverCmd.Flags().IntVarP(&serverPort, "port", "p", 8080, "Port to listen on")
-	serverCmd.Flags().StringVarP(&serverHost, "host", "H", "0.0.0.0", "Host to bind to")
+	serverCmd.Flags().IntVarP(&cfg.Server.Port, "port", "p", cfg.Server.Port, "Port to listen on")
+	serverCmd.Flags().StringVarP(&cfg.Server.Host, "host", "H", cfg.Server.Host, "Host to bind to")
+	serverCmd.Flags().DurationVar(&cfg.Server.StatusTimeout, "status-timeout", cfg.Server.StatusTimeout, "Default deadline for /api/v1/status (overridable with the timeout query parameter)")
+	serverCmd.Flags().DurationVar(&cfg.Server.MaxStatusTimeout, "max-status-timeout", cfg.Server.MaxStatusTimeout, "Largest timeout query parameter /api/v1/status accepts")
+	serverCmd.Flags().Int64Var(&cfg.Server.ListPageSize, "list-page-size", cfg.Server.ListPageSize, "Default page size for list endpoints when no limit query parameter is given")
+	serverCmd.Flags().BoolVar(&cfg.Server.ExposeInternalErrors, "expose-internal-errors", cfg.Server.ExposeInternalErrors, "Return the text of unexpected server errors to clients (default false when ENV is prod)")
+
+	// Authentication for /api endpoints (/health stays open)
+	serverCmd.Flags().StringVar(&cfg.Server.Authentication.TokenFile, "token-auth-file", cfg.Server.Authentication.TokenFile, "Static token file (CSV: token,user,uid,\"group1,group2\") used to authenticate bearer tokens")
+	serverCmd.Flags().BoolVar(&cfg.Server.Authentication.TokenWebhook, "authentication-token-webhook", cfg.Server.Authentication.TokenWebhook, "Authenticate bearer tokens (ServiceAccount, OIDC) with Kubernetes TokenReview")
+	serverCmd.Flags().DurationVar(&cfg.Server.Authentication.TokenWebhookCacheTTL, "authentication-token-webhook-cache-ttl", cfg.Server.Authentication.TokenWebhookCacheTTL, "How long TokenReview results are cached")
+	serverCmd.Flags().StringSliceVar(&cfg.Server.Authentication.APIAudiences, "api-audiences", cfg.Server.Authentication.APIAudiences, "Audiences required in tokens checked with TokenReview (default: API server audience)")
+
+	// Per-user authorization
+	serverCmd.Flags().StringVar(&cfg.Server.Authorization.Mode, "authorization-mode", cfg.Server.Authorization.Mode, "Authorization mode: AlwaysAllow, or SubjectAccessReview to check the caller's own RBAC")
+	serverCmd.Flags().DurationVar(&cfg.Server.Authorization.AllowedTTL, "authorization-webhook-cache-authorized-ttl", cfg.Server.Authorization.AllowedTTL, "How long allowed SubjectAccessReview decisions are cached")
+	serverCmd.Flags().DurationVar(&cfg.Server.Authorization.DeniedTTL, "authorization-webhook-cache-unauthorized-ttl", cfg.Server.Authorization.DeniedTTL, "How long denied SubjectAccessReview decisions are cached")
 }
 
 // Response represents a standard API response
 type Response struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Message string      `json:"message,omitempty"`
+	Success  bool        `json:"success"`
+	Data     interface{} `json:"data,omitempty"`
+	Error    *APIError   `json:"error,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Metadata *ListMeta   `json:"metadata,omitempty"`
+}
+
+// ListMeta carries pagination state for list endpoints
+type ListMeta struct {
+	// Continue is passed back as the continue query parameter to fetch the next page
+	Continue           string `json:"continue,omitempty"`
+	RemainingItemCount *int64 `json:"remainingItemCount,omitempty"`
 }
 
 // DeploymentStatus represents deployment status information
@@ -55,6 +90,24 @@ type DeploymentStatus struct {
 	Healthy           bool   `json:"healthy"`
 }
 
+// newDeploymentStatus summarizes the replica status of a deployment
+func newDeploymentStatus(deployment *appsv1.Deployment) DeploymentStatus {
+	desiredReplicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+
+	return DeploymentStatus{
+		Name:              deployment.Name,
+		Namespace:         deployment.Namespace,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		DesiredReplicas:   desiredReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		Healthy:           deployment.Status.ReadyReplicas >= desiredReplicas,
+	}
+}
+
 // Event represents a Kubernetes event
 type Event struct {
 	Type      string    `json:"type"`
@@ -64,10 +117,41 @@ type Event struct {
 	Object    string    `json:"object"`
 }
 
+// HealthStatus is returned by /health
+type HealthStatus struct {
+	Timestamp time.Time `json:"timestamp"`
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	// ConfigReloadedAt is the time of the last successful configuration reload
+	ConfigReloadedAt *time.Time `json:"config_reloaded_at,omitempty"`
+}
+
+// DeploymentList is one page of deployment statuses
+type DeploymentList struct {
+	Deployments []DeploymentStatus `json:"deployments"`
+	Namespace   string             `json:"namespace"`
+	Count       int                `json:"count"`
+}
+
+// EventList is one page of events
+type EventList struct {
+	Events    []Event `json:"events"`
+	Namespace string  `json:"namespace"`
+	Count     int     `json:"count"`
+}
+
+// AlertList holds the alerts of a namespace
+type AlertList struct {
+	Alerts    []alerts.Alert `json:"alerts"`
+	Namespace string         `json:"namespace"`
+	Count     int            `json:"count"`
+}
+
 func runServer(cmd *cobra.Command, args []string) {
 	log.Info("Starting HTTP server", map[string]interface{}{
-		"host": serverHost,
-		"port": serverPort,
+		"host":    cfg.Server.Host,
+		"port":    cfg.Server.Port,
+		"version": version.Get().Version,
 	})
 
 	clientset, err := getKubernetesClient()
@@ -75,14 +159,56 @@ func runServer(cmd *cobra.Command, args []string) {
 		log.Fatal("Failed to get Kubernetes client", err, nil)
 	}
 
+	authenticator, err := newAuthenticator(clientset)
+	if err != nil {
+		log.Fatal("Failed to configure authentication", err, nil)
+	}
+	if authenticator == nil {
+		log.Warn("Authentication is disabled; anyone who can reach the server can read cluster state", nil)
+	}
+
+	authorizer, err := newAuthorizer(clientset, authenticator)
+	if err != nil {
+		log.Fatal("Failed to configure authorization", err, nil)
+	}
+
+	corsPolicy, err := newCORSPolicy()
+	if err != nil {
+		log.Fatal("Failed to configure CORS", err, nil)
+	}
+
+	limits, err := newRateLimits()
+	if err != nil {
+		log.Fatal("Failed to configure rate limits", err, nil)
+	}
+	limits.logConfig()
+
+	// CORS rules and rate limits change with the config file
+	reloader := newConfigReloader()
+	reloader.handle(func(c *config.Config) error { return corsPolicy.Configure(c.Server.CORS) }, "server.cors")
+	reloader.handle(func(c *config.Config) error { return limits.update(c.Server.RateLimits) }, "server.rateLimits")
+	alerting := startAlerting(cmd.Context(), clientset, reloader)
+	reloader.watch(cmd.Context())
+
+	// The API server must answer before the server starts and while it is ready
+	probes := newProbes()
+	apiServer := apiServerCheck(clientset)
+	probes.readyz.Add(apiServer)
+	probes.startupz.Add(healthz.Once(apiServer))
+
+	handler, err := createHandler(clientset, authenticator, authorizer, corsPolicy, limits, probes, alerting.engine)
+	if err != nil {
+		log.Fatal("Failed to create HTTP handler", err, nil)
+	}
+
 	// Create FastHTTP server
 	server := &fasthttp.Server{
-		Handler: createHandler(clientset),
+		Handler: handler,
 		Name:    "k8s-controller-server",
 	}
 
 	// Create listener
-	addr := fmt.Sprintf("%s:%d", serverHost, serverPort)
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Fatal("Failed to create listener", err, map[string]interface{}{
@@ -90,8 +216,27 @@ func runServer(cmd *cobra.Command, args []string) {
 		})
 	}
 
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		tlsConfig, err := newTLSConfig(cmd.Context())
+		if err != nil {
+			log.Fatal("Failed to configure TLS", err, nil)
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
+
+		if cfg.Server.HTTPRedirectPort != 0 {
+			serveHTTPRedirect(cfg.Server.HTTPRedirectPort)
+		}
+	}
+
+	if cfg.Server.HealthPort != 0 {
+		serveHealthPort(cfg.Server.Host, cfg.Server.HealthPort, probes)
+	}
+
 	log.Info("HTTP server started successfully", map[string]interface{}{
 		"address": addr,
+		"scheme":  scheme,
 	})
 
 	// Start server
@@ -100,55 +245,157 @@ func runServer(cmd *cobra.Command, args []string) {
 	}
 }
 
-func createHandler(clientset *kubernetes.Clientset) fasthttp.RequestHandler {
-	return func(ctx *fasthttp.RequestCtx) {
-		// Set CORS headers
-		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
-		ctx.Response.Header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type")
-
-		// Handle preflight requests
-		if ctx.IsOptions() {
-			ctx.SetStatusCode(fasthttp.StatusOK)
-			return
-		}
+func createHandler(clientset *kubernetes.Clientset, authenticator auth.Authenticator, authorizer auth.Authorizer, corsPolicy *cors.Policy, limits *rateLimits, probes *probes, alertEngine *alerts.Engine) (fasthttp.RequestHandler, error) {
+	r, err := newRouter(clientset, authenticator, authorizer, corsPolicy, limits, probes, alertEngine)
+	if err != nil {
+		return nil, err
+	}
+	return r.Handler(), nil
+}
+
+// newRouter registers every route and generates the OpenAPI document from the
+// resulting route table
+func newRouter(clientset *kubernetes.Clientset, authenticator auth.Authenticator, authorizer auth.Authorizer, corsPolicy *cors.Policy, limits *rateLimits, probes *probes, alertEngine *alerts.Engine) (*router.Router, error) {
+	r := router.New()
+	r.NotFound = handleNotFound
+	r.MethodNotAllowed = handleMethodNotAllowed
+	r.Use(compressionMiddleware(), commonHeadersMiddleware, tracingMiddleware, corsPolicy.Middleware(r.Allowed))
+
+	docs := &openAPIHandler{}
+	r.GET("/health", cacheControl(cacheNever)(handleHealth))
+	r.GET("/metrics", cacheControl(cacheNever)(metrics.Handler()))
+	r.GET("/version", cacheControl(cacheStatic)(handleVersion))
+	probes.register(r)
+	r.GET(openAPIPath, cacheControl(cacheStatic)(docs.serveSpec))
+	r.GET(docsPath, cacheControl(cacheStatic)(docs.serveDocs))
+
+	// Versioned API groups; a future /api/v2 gets its own group and middleware
+	v1 := r.Group("/api/v1",
+		limits.concurrency(),
+		limits.byIP(rateLimitGroupAPI),
+		authenticationMiddleware(authenticator),
+		limits.byUser(rateLimitGroupAPI),
+		cacheControl(cacheRevalidate),
+	)
+	registerV1Routes(v1, clientset, authorizer, limits, alertEngine)
+
+	// The document is generated from the final route table
+	if err := docs.load(r.Routes()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
 
+// registerV1Routes registers the /api/v1 endpoints. Namespaced endpoints are
+// available both as /namespaces/{namespace}/... and with a namespace query parameter.
+// Each route requires the same RBAC permission kubectl would need.
+func registerV1Routes(v1 *router.Group, clientset *kubernetes.Clientset, authorizer auth.Authorizer, limits *rateLimits, alertEngine *alerts.Engine) {
+	listDeployments := requireAccess(authorizer, "list", "apps", "deployments")
+	listEvents := requireAccess(authorizer, "list", "", "events")
+	watchDeploymentsAccess := requireAccess(authorizer, "watch", "apps", "deployments")
+
+	getDeployments := func(ctx *fasthttp.RequestCtx) { handleGetDeployments(ctx, clientset) }
+	getEvents := func(ctx *fasthttp.RequestCtx) { handleGetEvents(ctx, clientset) }
+	getAlerts := func(ctx *fasthttp.RequestCtx) { handleGetAlerts(ctx, alertEngine, authorizer) }
+	streamDeployments := cacheControl(cacheNever)(func(ctx *fasthttp.RequestCtx) { handleWatchDeployments(ctx, clientset) })
+	getStatus := func(ctx *fasthttp.RequestCtx) { handleGetStatus(ctx, clientset, authorizer) }
+
+	// Status fans out to several API server calls, so it has tighter limits
+	getStatus = limits.byIP(rateLimitGroupStatus)(limits.byUser(rateLimitGroupStatus)(getStatus))
+
+	v1.GET("/deployments", listDeployments(getDeployments))
+	v1.GET("/watch/deployments", watchDeploymentsAccess(streamDeployments))
+	v1.GET("/events", listEvents(getEvents))
+	v1.GET("/status", getStatus)
+	v1.GET("/alerts", getAlerts)
+
+	namespaced := v1.Group("/namespaces/{namespace}")
+	namespaced.GET("/deployments", listDeployments(getDeployments))
+	namespaced.GET("/watch/deployments", watchDeploymentsAccess(streamDeployments))
+	namespaced.GET("/events", listEvents(getEvents))
+	namespaced.GET("/status", getStatus)
+	namespaced.GET("/alerts", getAlerts)
+
+	// Single objects
+	namespaced.GET("/deployments/{name}", requireAccess(authorizer, "get", "apps", "deployments")(
+		func(ctx *fasthttp.RequestCtx) { handleGetResource(ctx, clientset, "Deployment", getDeploymentDetail) }))
+	namespaced.GET("/replicasets/{name}", requireAccess(authorizer, "get", "apps", "replicasets")(
+		func(ctx *fasthttp.RequestCtx) { handleGetResource(ctx, clientset, "ReplicaSet", getReplicaSetDetail) }))
+	namespaced.GET("/pods/{name}", requireAccess(authorizer, "get", "", "pods")(
+		func(ctx *fasthttp.RequestCtx) { handleGetResource(ctx, clientset, "Pod", getPodDetail) }))
+	namespaced.GET("/services/{name}", requireAccess(authorizer, "get", "", "services")(
+		func(ctx *fasthttp.RequestCtx) { handleGetResource(ctx, clientset, "Service", getServiceDetail) }))
+	namespaced.GET("/events/{name}", requireAccess(authorizer, "get", "", "events")(
+		func(ctx *fasthttp.RequestCtx) { handleGetResource(ctx, clientset, "Event", getEventDetail) }))
+}
+
+// commonHeadersMiddleware sets the content type header on every response
+func commonHeadersMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
 		// Set content type
 		ctx.Response.Header.Set("Content-Type", "application/json")
 
+		next(ctx)
+	}
+}
+
+// tracingMiddleware starts a server span for every request and stores the
+// traced context for handlers
+func tracingMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
 		path := string(ctx.Path())
 		method := string(ctx.Method())
 
-		log.Debug("HTTP request", map[string]interface{}{
+		// Client-go calls made with the request context become child spans
+		spanCtx, span := tracing.StartServerSpan(ctx, method+" "+path)
+		defer tracing.EndServerSpan(ctx, span)
+		ctx.SetUserValue(requestContextKey, spanCtx)
+
+		log.WithContext(spanCtx).Debug("HTTP request", map[string]interface{}{
 			"method": method,
 			"path":   path,
 			"remote": ctx.RemoteAddr(),
 		})
 
-		switch {
-		case path == "/health" && method == "GET":
-			handleHealth(ctx)
-		case path == "/api/v1/deployments" && method == "GET":
-			handleGetDeployments(ctx, clientset)
-		case path == "/api/v1/deployments" && method == "POST":
-			handleWatchDeployments(ctx)
-		case path == "/api/v1/events" && method == "GET":
-			handleGetEvents(ctx, clientset)
-		case path == "/api/v1/status" && method == "GET":
-			handleGetStatus(ctx, clientset)
-		default:
-			handleNotFound(ctx)
+		next(ctx)
+
+		// Name the span after the route pattern to keep span names low-cardinality
+		if route := router.MatchedRoute(ctx); route != "" {
+			span.SetName(method + " " + route)
 		}
 	}
 }
 
+// requestNamespace returns the namespace from the path, the namespace query
+// parameter, or "default"
+func requestNamespace(ctx *fasthttp.RequestCtx) string {
+	if namespace := router.Param(ctx, "namespace"); namespace != "" {
+		return namespace
+	}
+	if namespace := string(ctx.QueryArgs().Peek("namespace")); namespace != "" {
+		return namespace
+	}
+	return "default"
+}
+
+// requestContext returns the traced context of a request
+func requestContext(ctx *fasthttp.RequestCtx) context.Context {
+	if reqCtx, ok := ctx.UserValue(requestContextKey).(context.Context); ok {
+		return reqCtx
+	}
+	return ctx
+}
+
 func handleHealth(ctx *fasthttp.RequestCtx) {
 	response := Response{
 		Success: true,
 		Message: "Server is healthy",
-		Data: map[string]interface{}{
-			"timestamp": time.Now().UTC(),
-			"version":   "1.0.0",
+		Data: HealthStatus{
+			Timestamp:        time.Now().UTC(),
+			Version:          version.Get().Version,
+			Commit:           version.Get().Commit,
+			ConfigReloadedAt: configReloadedAt.Load(),
 		},
 	}
 
@@ -157,59 +404,68 @@ func handleHealth(ctx *fasthttp.RequestCtx) {
 	ctx.SetStatusCode(fasthttp.StatusOK)
 }
 
+func handleVersion(ctx *fasthttp.RequestCtx) {
+	response := Response{
+		Success: true,
+		Message: "Version retrieved successfully",
+		Data:    version.Get(),
+	}
+
+	jsonResponse, _ := json.Marshal(response)
+	ctx.SetBody(jsonResponse)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
 func handleGetDeployments(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset) {
-	namespace := string(ctx.QueryArgs().Peek("namespace"))
-	if namespace == "" {
-		namespace = "default"
+	namespace := requestNamespace(ctx)
+
+	listOptions, err := listOptionsFromQuery(ctx, cfg.Server.ListPageSize)
+	if err != nil {
+		sendErrorResponse(ctx, "Invalid list parameters", err, fasthttp.StatusBadRequest)
+		return
 	}
 
-	namespaceLogger := log.WithNamespace(namespace)
+	reqCtx := requestContext(ctx)
+	namespaceLogger := log.WithContext(reqCtx).WithNamespace(namespace)
 	namespaceLogger.Info("HTTP request: Get deployments", map[string]interface{}{
 		"namespace": namespace,
+		"limit":     listOptions.Limit,
+		"continue":  listOptions.Continue != "",
 	})
 
-	deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(reqCtx, listOptions)
 	if err != nil {
 		namespaceLogger.Error("Failed to get deployments", err, nil)
 		sendErrorResponse(ctx, "Failed to get deployments", err, fasthttp.StatusInternalServerError)
 		return
 	}
+	if listNotModified(ctx, "Deployment", namespace, listOptions, deployments, &deployments.ListMeta) {
+		return
+	}
 
 	var deploymentStatuses []DeploymentStatus
-	for _, deployment := range deployments.Items {
-		readyReplicas := deployment.Status.ReadyReplicas
-		desiredReplicas := *deployment.Spec.Replicas
-		availableReplicas := deployment.Status.AvailableReplicas
-
-		status := DeploymentStatus{
-			Name:              deployment.Name,
-			Namespace:         deployment.Namespace,
-			ReadyReplicas:     readyReplicas,
-			DesiredReplicas:   desiredReplicas,
-			AvailableReplicas: availableReplicas,
-			UpdatedReplicas:   deployment.Status.UpdatedReplicas,
-			Healthy:           readyReplicas >= desiredReplicas,
-		}
-
+	for i := range deployments.Items {
+		status := newDeploymentStatus(&deployments.Items[i])
 		deploymentStatuses = append(deploymentStatuses, status)
 
 		// Log deployment status
-		deploymentLogger := namespaceLogger.WithDeployment(deployment.Name)
+		deploymentLogger := namespaceLogger.WithDeployment(status.Name)
 		deploymentLogger.Info("Deployment status retrieved", map[string]interface{}{
-			"ready_replicas":     readyReplicas,
-			"desired_replicas":   desiredReplicas,
-			"available_replicas": availableReplicas,
+			"ready_replicas":     status.ReadyReplicas,
+			"desired_replicas":   status.DesiredReplicas,
+			"available_replicas": status.AvailableReplicas,
 			"healthy":            status.Healthy,
 		})
 	}
 
 	response := Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"deployments": deploymentStatuses,
-			"namespace":   namespace,
-			"count":       len(deploymentStatuses),
+		Data: DeploymentList{
+			Deployments: deploymentStatuses,
+			Namespace:   namespace,
+			Count:       len(deploymentStatuses),
 		},
+		Metadata: listMeta(&deployments.ListMeta),
 	}
 
 	jsonResponse, _ := json.Marshal(response)
@@ -217,16 +473,70 @@ func handleGetDeployments(ctx *fasthttp.RequestCtx, clientset *kubernetes.Client
 	ctx.SetStatusCode(fasthttp.StatusOK)
 }
 
-func handleWatchDeployments(ctx *fasthttp.RequestCtx) {
-	// This endpoint would implement Server-Sent Events (SSE) for real-time updates
-	// For now, we'll return a simple response indicating the feature
+// alertResources are the resources alerts are derived from, which callers
+// need list access to in order to see them
+var alertResources = []struct{ group, name string }{
+	{"apps", "deployments"},
+	{"", "pods"},
+	{"", "events"},
+}
+
+// handleGetAlerts lists the alerts of a namespace. Alerts derived from a
+// resource the caller may not list are left out and reported in warnings; it
+// answers 403 when the caller may list none of them.
+func handleGetAlerts(ctx *fasthttp.RequestCtx, alertEngine *alerts.Engine, authorizer auth.Authorizer) {
+	namespace := requestNamespace(ctx)
+
+	allowed := make(map[string]bool, len(alertResources))
+	var (
+		warnings  []string
+		forbidden error
+	)
+	for _, resource := range alertResources {
+		attrs := auth.Attributes{Verb: "list", Group: resource.group, Resource: resource.name, Namespace: namespace}
+		err := checkAccess(ctx, authorizer, attrs)
+		var denied *ForbiddenError
+		switch {
+		case err == nil:
+			allowed[resource.name] = true
+		case errors.As(err, &denied):
+			forbidden = err
+			warnings = append(warnings, fmt.Sprintf("%s: %v; its alerts are left out", resource.name, err))
+		default:
+			log.WithContext(requestContext(ctx)).Error("Authorization unavailable", err, map[string]interface{}{
+				"path":   string(ctx.Path()),
+				"access": attrs.String(),
+			})
+			sendErrorResponse(ctx, "Authorization unavailable", err, fasthttp.StatusServiceUnavailable)
+			return
+		}
+	}
+	if len(allowed) == 0 {
+		sendErrorResponse(ctx, "Forbidden", forbidden, fasthttp.StatusForbidden)
+		return
+	}
+
+	state := string(ctx.QueryArgs().Peek("state"))
+	switch state {
+	case "", alerts.StatePending, alerts.StateFiring, alerts.StateResolved:
+	default:
+		err := fmt.Errorf("invalid state %q (expected %s, %s or %s)", state, alerts.StatePending, alerts.StateFiring, alerts.StateResolved)
+		sendErrorResponse(ctx, "Invalid alert parameters", err, fasthttp.StatusBadRequest)
+		return
+	}
+
+	list := AlertList{Alerts: []alerts.Alert{}, Namespace: namespace}
+	for _, alert := range alertEngine.Alerts() {
+		if alert.Namespace == namespace && allowed[alert.Resource] && (state == "" || alert.State == state) {
+			list.Alerts = append(list.Alerts, alert)
+		}
+	}
+	list.Count = len(list.Alerts)
+
 	response := Response{
-		Success: true,
-		Message: "Watch endpoint - implement SSE for real-time updates",
-		Data: map[string]interface{}{
-			"feature": "deployment_watching",
-			"status":  "not_implemented",
-		},
+		Success:  true,
+		Data:     list,
+		Warnings: warnings,
 	}
 
 	jsonResponse, _ := json.Marshal(response)
@@ -235,33 +545,31 @@ func handleWatchDeployments(ctx *fasthttp.RequestCtx) {
 }
 
 func handleGetEvents(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset) {
-	namespace := string(ctx.QueryArgs().Peek("namespace"))
-	if namespace == "" {
-		namespace = "default"
-	}
+	namespace := requestNamespace(ctx)
 
-	limitStr := string(ctx.QueryArgs().Peek("limit"))
-	limit := 10
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	listOptions, err := listOptionsFromQuery(ctx, 10)
+	if err != nil {
+		sendErrorResponse(ctx, "Invalid list parameters", err, fasthttp.StatusBadRequest)
+		return
 	}
 
-	namespaceLogger := log.WithNamespace(namespace)
+	reqCtx := requestContext(ctx)
+	namespaceLogger := log.WithContext(reqCtx).WithNamespace(namespace)
 	namespaceLogger.Info("HTTP request: Get events", map[string]interface{}{
 		"namespace": namespace,
-		"limit":     limit,
+		"limit":     listOptions.Limit,
+		"continue":  listOptions.Continue != "",
 	})
 
-	events, err := clientset.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
-		Limit: int64(limit),
-	})
+	events, err := clientset.CoreV1().Events(namespace).List(reqCtx, listOptions)
 	if err != nil {
 		namespaceLogger.Error("Failed to get events", err, nil)
 		sendErrorResponse(ctx, "Failed to get events", err, fasthttp.StatusInternalServerError)
 		return
 	}
+	if listNotModified(ctx, "Event", namespace, listOptions, events, &events.ListMeta) {
+		return
+	}
 
 	var eventList []Event
 	for _, event := range events.Items {
@@ -295,11 +603,12 @@ func handleGetEvents(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset)
 
 	response := Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"events":    eventList,
-			"namespace": namespace,
-			"count":     len(eventList),
+		Data: EventList{
+			Events:    eventList,
+			Namespace: namespace,
+			Count:     len(eventList),
 		},
+		Metadata: listMeta(&events.ListMeta),
 	}
 
 	jsonResponse, _ := json.Marshal(response)
@@ -307,107 +616,82 @@ func handleGetEvents(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset)
 	ctx.SetStatusCode(fasthttp.StatusOK)
 }
 
-func handleGetStatus(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset) {
-	namespace := string(ctx.QueryArgs().Peek("namespace"))
-	if namespace == "" {
-		namespace = "default"
-	}
+func handleNotFound(ctx *fasthttp.RequestCtx) {
+	sendErrorResponse(ctx, "Endpoint not found",
+		fmt.Errorf("no endpoint matches %s", ctx.Path()), fasthttp.StatusNotFound)
+}
 
-	namespaceLogger := log.WithNamespace(namespace)
-	namespaceLogger.Info("HTTP request: Get cluster status", map[string]interface{}{
-		"namespace": namespace,
-	})
+func handleMethodNotAllowed(ctx *fasthttp.RequestCtx) {
+	sendErrorResponse(ctx, "Method not allowed",
+		fmt.Errorf("method %s is not allowed; allowed methods: %s", ctx.Method(), ctx.Response.Header.Peek("Allow")),
+		fasthttp.StatusMethodNotAllowed)
+}
 
-	// Get deployments
-	deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		namespaceLogger.Error("Failed to get deployments", err, nil)
-		sendErrorResponse(ctx, "Failed to get deployments", err, fasthttp.StatusInternalServerError)
-		return
-	}
+// sendErrorResponse answers with a typed error. Typed and Kubernetes API
+// errors choose their own status code; statusCode is used for other errors.
+func sendErrorResponse(ctx *fasthttp.RequestCtx, message string, err error, statusCode int) {
+	apiErr := newAPIError(err, statusCode)
+	apiErr.sanitize(requestContext(ctx))
 
-	// Get pods
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		namespaceLogger.Error("Failed to get pods", err, nil)
-		sendErrorResponse(ctx, "Failed to get pods", err, fasthttp.StatusInternalServerError)
-		return
+	response := Response{
+		Success: false,
+		Error:   apiErr,
+		Message: message,
 	}
 
-	// Get services
-	services, err := clientset.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		namespaceLogger.Error("Failed to get services", err, nil)
-		sendErrorResponse(ctx, "Failed to get services", err, fasthttp.StatusInternalServerError)
-		return
-	}
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, cacheNever)
+	jsonResponse, _ := json.Marshal(response)
+	ctx.SetBody(jsonResponse)
+	ctx.SetStatusCode(apiErr.Code)
+}
 
-	// Calculate pod status
-	podStatus := make(map[corev1.PodPhase]int32)
-	for _, pod := range pods.Items {
-		podStatus[pod.Status.Phase]++
+// listOptionsFromQuery builds list options from the limit and continue query
+// parameters, using defaultLimit when no limit is given
+func listOptionsFromQuery(ctx *fasthttp.RequestCtx, defaultLimit int64) (metav1.ListOptions, error) {
+	options := metav1.ListOptions{
+		Limit:    defaultLimit,
+		Continue: string(ctx.QueryArgs().Peek("continue")),
 	}
 
-	// Calculate deployment health
-	healthyDeployments := 0
-	unhealthyDeployments := 0
-	for _, deployment := range deployments.Items {
-		if deployment.Status.ReadyReplicas >= *deployment.Spec.Replicas {
-			healthyDeployments++
-		} else {
-			unhealthyDeployments++
+	if limitStr := string(ctx.QueryArgs().Peek("limit")); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit <= 0 {
+			return options, fmt.Errorf("limit must be a positive integer: %q", limitStr)
 		}
+		options.Limit = limit
 	}
 
-	status := map[string]interface{}{
-		"namespace": map[string]interface{}{
-			"name": namespace,
-		},
-		"deployments": map[string]interface{}{
-			"total":     len(deployments.Items),
-			"healthy":   healthyDeployments,
-			"unhealthy": unhealthyDeployments,
-		},
-		"pods": map[string]interface{}{
-			"total":  len(pods.Items),
-			"status": podStatus,
-		},
-		"services": map[string]interface{}{
-			"total": len(services.Items),
-		},
-		"timestamp": time.Now().UTC(),
-	}
-
-	response := Response{
-		Success: true,
-		Data:    status,
-	}
-
-	jsonResponse, _ := json.Marshal(response)
-	ctx.SetBody(jsonResponse)
-	ctx.SetStatusCode(fasthttp.StatusOK)
+	return options, nil
 }
 
-func handleNotFound(ctx *fasthttp.RequestCtx) {
-	response := Response{
-		Success: false,
-		Error:   "Endpoint not found",
-		Message: "The requested endpoint does not exist",
+// listNotModified sets an ETag derived from the listed objects and the page
+// requested, and answers 304 when the client already has this page
+func listNotModified(ctx *fasthttp.RequestCtx, kind, namespace string, options metav1.ListOptions, list runtime.Object, meta *metav1.ListMeta) bool {
+	versions := &versionSet{}
+	if err := versions.add(kind, list); err != nil {
+		log.WithContext(requestContext(ctx)).Warn("Failed to compute ETag", map[string]interface{}{
+			"kind":  kind,
+			"error": err.Error(),
+		})
+		return false
 	}
 
-	jsonResponse, _ := json.Marshal(response)
-	ctx.SetBody(jsonResponse)
-	ctx.SetStatusCode(fasthttp.StatusNotFound)
+	// The continue token in the response changes on every call, so only the
+	// requested page and the number of remaining items are part of the ETag
+	versions.addString("namespace="+namespace, "limit="+strconv.FormatInt(options.Limit, 10), "continue="+options.Continue)
+	if meta.RemainingItemCount != nil {
+		versions.addString("remaining=" + strconv.FormatInt(*meta.RemainingItemCount, 10))
+	}
+	if meta.Continue != "" {
+		versions.addString("more")
+	}
+	return notModified(ctx, versions.etag())
 }
 
-func sendErrorResponse(ctx *fasthttp.RequestCtx, message string, err error, statusCode int) {
-	response := Response{
-		Success: false,
-		Error:   message,
-		Message: err.Error(),
+// listMeta returns pagination metadata for a list response
+func listMeta(meta *metav1.ListMeta) *ListMeta {
+	return &ListMeta{
+		Continue:           meta.Continue,
+		RemainingItemCount: meta.RemainingItemCount,
 	}
-
-	jsonResponse, _ := json.Marshal(response)
-	ctx.SetBody(jsonResponse)
-	ctx.SetStatusCode(statusCode)
 }
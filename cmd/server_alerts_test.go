@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+)
+
+// resourceAuthorizer allows listing the resources mapped to true and fails
+// for the ones mapped to false when unavailable is set
+type resourceAuthorizer struct {
+	allowed     map[string]bool
+	unavailable bool
+}
+
+func (a resourceAuthorizer) Authorize(ctx context.Context, user *auth.UserInfo, attrs auth.Attributes) (auth.Decision, error) {
+	if a.unavailable {
+		return auth.Decision{}, &auth.UnavailableError{Review: "subject access review", Err: errors.New("connection refused")}
+	}
+	return auth.Decision{Allowed: a.allowed[attrs.Resource]}, nil
+}
+
+// newTestAlertEngine returns an engine with a firing alert for a deployment
+// and one for a warning event in namespace shop
+func newTestAlertEngine(t *testing.T) *alerts.Engine {
+	t.Helper()
+	replicas := int32(2)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "shop", Name: "web-abc.1"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-abc"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedMount",
+			Message:        "secret shop-db-password not found",
+			LastTimestamp:  metav1.Now(),
+		},
+	)
+
+	engine := alerts.NewEngine(client, "", 100, time.Minute)
+	err := engine.SetRules([]alerts.Rule{
+		{Name: "NotReady", ReadyBelowDesired: &alerts.ReadyBelowDesired{}},
+		{Name: "Warnings", WarningEvent: &alerts.WarningEvent{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Evaluate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func TestAlertsFilteredByAccess(t *testing.T) {
+	engine := newTestAlertEngine(t)
+
+	tests := []struct {
+		name       string
+		authorizer auth.Authorizer
+		code       int
+		rules      []string
+		warnings   int
+	}{
+		{
+			name:       "all allowed",
+			authorizer: auth.AlwaysAllow{},
+			code:       fasthttp.StatusOK,
+			rules:      []string{"NotReady", "Warnings"},
+		},
+		{
+			name:       "deployments only",
+			authorizer: resourceAuthorizer{allowed: map[string]bool{"deployments": true}},
+			code:       fasthttp.StatusOK,
+			rules:      []string{"NotReady"},
+			warnings:   2,
+		},
+		{
+			name:       "events only",
+			authorizer: resourceAuthorizer{allowed: map[string]bool{"events": true}},
+			code:       fasthttp.StatusOK,
+			rules:      []string{"Warnings"},
+			warnings:   2,
+		},
+		{
+			name:       "nothing allowed",
+			authorizer: resourceAuthorizer{},
+			code:       fasthttp.StatusForbidden,
+		},
+		{
+			name:       "authorizer unavailable",
+			authorizer: resourceAuthorizer{unavailable: true},
+			code:       fasthttp.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/api/v1/alerts?namespace=shop")
+			handleGetAlerts(ctx, engine, tt.authorizer)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Fatalf("status = %d, want %d: %s", ctx.Response.StatusCode(), tt.code, ctx.Response.Body())
+			}
+			if tt.code != fasthttp.StatusOK {
+				if strings.Contains(string(ctx.Response.Body()), "shop-db-password") {
+					t.Errorf("error response leaks an event message: %s", ctx.Response.Body())
+				}
+				return
+			}
+
+			var response struct {
+				Data     AlertList `json:"data"`
+				Warnings []string  `json:"warnings"`
+			}
+			if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+				t.Fatal(err)
+			}
+			var rules []string
+			for _, alert := range response.Data.Alerts {
+				rules = append(rules, alert.Rule)
+			}
+			if strings.Join(rules, ",") != strings.Join(tt.rules, ",") {
+				t.Errorf("alerts of rules %v, want %v", rules, tt.rules)
+			}
+			if len(response.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", response.Warnings, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// userKey is the fasthttp user value holding the authenticated caller
+const userKey = "auth_user"
+
+// newAuthenticator builds the authenticator chain from server flags. It
+// returns nil when no authentication method is configured.
+func newAuthenticator(clientset *kubernetes.Clientset) (auth.Authenticator, error) {
+	var authenticators auth.Union
+
+	if cfg.Server.Authentication.TokenFile != "" {
+		tokenFile, err := auth.NewTokenFile(cfg.Server.Authentication.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, tokenFile)
+	}
+	if cfg.Server.Authentication.TokenWebhook {
+		authenticators = append(authenticators, auth.NewTokenReview(clientset, cfg.Server.Authentication.APIAudiences, cfg.Server.Authentication.TokenWebhookCacheTTL))
+	}
+	if len(authenticators) == 0 && cfg.Server.TLS.ClientCAFile == "" {
+		return nil, nil
+	}
+
+	// Verified client certificates are accepted whenever TLS is on
+	authenticators = append(authenticators, auth.ClientCert{})
+	return authenticators, nil
+}
+
+// newAuthorizer builds the authorizer for the configured authorization mode
+func newAuthorizer(clientset *kubernetes.Clientset, authenticator auth.Authenticator) (auth.Authorizer, error) {
+	switch cfg.Server.Authorization.Mode {
+	case auth.ModeAlwaysAllow:
+		return auth.AlwaysAllow{}, nil
+	case auth.ModeSubjectAccessReview:
+		if authenticator == nil {
+			return nil, fmt.Errorf("authorization mode %s requires an authentication method", cfg.Server.Authorization.Mode)
+		}
+		return auth.NewSubjectAccessReview(clientset, cfg.Server.Authorization.AllowedTTL, cfg.Server.Authorization.DeniedTTL), nil
+	}
+	return nil, fmt.Errorf("unknown authorization mode %q (expected %s or %s)", cfg.Server.Authorization.Mode, auth.ModeAlwaysAllow, auth.ModeSubjectAccessReview)
+}
+
+// authenticationMiddleware rejects requests without valid credentials with 401
+func authenticationMiddleware(authenticator auth.Authenticator) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		if authenticator == nil {
+			return next
+		}
+
+		return func(ctx *fasthttp.RequestCtx) {
+			user, ok, err := authenticator.Authenticate(ctx)
+			var unavailable *auth.UnavailableError
+			if errors.As(err, &unavailable) {
+				log.WithContext(requestContext(ctx)).Error("Authentication unavailable", err, map[string]interface{}{
+					"path": string(ctx.Path()),
+				})
+				sendErrorResponse(ctx, "Authentication unavailable", err, fasthttp.StatusServiceUnavailable)
+				return
+			}
+			if !ok {
+				if err == nil {
+					err = errors.New("no credentials provided; use a bearer token or a client certificate")
+				}
+				log.WithContext(requestContext(ctx)).Warn("Unauthenticated request", map[string]interface{}{
+					"path":   string(ctx.Path()),
+					"remote": ctx.RemoteIP().String(),
+					"reason": err.Error(),
+				})
+				ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, `Bearer realm="k8s-controller"`)
+				sendErrorResponse(ctx, "Unauthorized", err, fasthttp.StatusUnauthorized)
+				return
+			}
+
+			ctx.SetUserValue(userKey, user)
+			trace.SpanFromContext(requestContext(ctx)).SetAttributes(attribute.String("enduser.id", user.Name))
+			next(ctx)
+		}
+	}
+}
+
+// requestUser returns the authenticated caller, or nil when authentication is disabled
+func requestUser(ctx *fasthttp.RequestCtx) *auth.UserInfo {
+	user, _ := ctx.UserValue(userKey).(*auth.UserInfo)
+	return user
+}
+
+// checkAccess asks the authorizer whether the caller may perform an action in
+// the request's namespace. It returns a *ForbiddenError when access is denied.
+func checkAccess(ctx *fasthttp.RequestCtx, authorizer auth.Authorizer, attrs auth.Attributes) error {
+	user := requestUser(ctx)
+	decision, err := authorizer.Authorize(requestContext(ctx), user, attrs)
+	if err != nil {
+		return err
+	}
+	if !decision.Allowed {
+		userName := ""
+		if user != nil {
+			userName = user.Name
+		}
+		return &ForbiddenError{User: userName, Attributes: attrs, Reason: decision.Reason}
+	}
+	return nil
+}
+
+// requireAccess returns middleware that allows a request only if the caller may
+// perform verb on group/resource in the request's namespace (and named object)
+func requireAccess(authorizer auth.Authorizer, verb, group, resource string) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			attrs := auth.Attributes{
+				Verb:      verb,
+				Group:     group,
+				Resource:  resource,
+				Namespace: requestNamespace(ctx),
+				Name:      router.Param(ctx, "name"),
+			}
+
+			err := checkAccess(ctx, authorizer, attrs)
+			var forbidden *ForbiddenError
+			switch {
+			case errors.As(err, &forbidden):
+				log.WithContext(requestContext(ctx)).Warn("Request not authorized", map[string]interface{}{
+					"path":   string(ctx.Path()),
+					"access": attrs.String(),
+					"reason": err.Error(),
+				})
+				sendErrorResponse(ctx, "Forbidden", err, fasthttp.StatusForbidden)
+				return
+			case err != nil:
+				// The authorizer could not decide; the caller may retry
+				log.WithContext(requestContext(ctx)).Error("Authorization unavailable", err, map[string]interface{}{
+					"path":   string(ctx.Path()),
+					"access": attrs.String(),
+				})
+				sendErrorResponse(ctx, "Authorization unavailable", err, fasthttp.StatusServiceUnavailable)
+				return
+			}
+			next(ctx)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/compress"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// Cache-Control values for the kinds of routes
+const (
+	// cacheRevalidate lets clients keep responses but check the ETag every
+	// time; responses depend on the caller's permissions, so shared caches
+	// must not store them
+	cacheRevalidate = "private, no-cache"
+	// cacheStatic is for content that only changes with a new server version
+	cacheStatic = "public, max-age=3600"
+	// cacheNever is for live data and errors
+	cacheNever = "no-store"
+)
+
+func init() {
+	serverCmd.Flags().BoolVar(&cfg.Server.Compression.Enabled, "compression", cfg.Server.Compression.Enabled, "Compress responses with zstd, brotli or gzip as negotiated with Accept-Encoding")
+	serverCmd.Flags().IntVar(&cfg.Server.Compression.MinSize, "compression-min-size", cfg.Server.Compression.MinSize, "Smallest response body in bytes that is compressed")
+}
+
+// compressionMiddleware compresses responses when compression is enabled
+func compressionMiddleware() router.Middleware {
+	if !cfg.Server.Compression.Enabled {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler { return next }
+	}
+	return compress.Middleware(cfg.Server.Compression.MinSize)
+}
+
+// cacheControl returns middleware that sets the Cache-Control header of a route
+func cacheControl(value string) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, value)
+			next(ctx)
+		}
+	}
+}
+
+// notModified sets the ETag of a response and answers 304 when the request's
+// If-None-Match matches it. It reports whether the response is complete.
+func notModified(ctx *fasthttp.RequestCtx, etag string) bool {
+	ctx.Response.Header.Set(fasthttp.HeaderETag, etag)
+	if compress.ETagMatches(string(ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch)), etag) {
+		ctx.SetStatusCode(fasthttp.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// versionSet collects the resourceVersions of listed objects to derive a
+// strong ETag that changes exactly when one of the objects changes
+type versionSet struct {
+	entries []string
+}
+
+// add records the name and resourceVersion of every item in a list
+func (v *versionSet) add(kind string, list runtime.Object) error {
+	var entries []string
+	err := meta.EachListItem(list, func(obj runtime.Object) error {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, kind+"/"+accessor.GetNamespace()+"/"+accessor.GetName()+"="+accessor.GetResourceVersion())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	v.entries = append(v.entries, entries...)
+	return nil
+}
+
+// addString records anything else the response depends on, such as query
+// parameters or warnings
+func (v *versionSet) addString(values ...string) {
+	v.entries = append(v.entries, values...)
+}
+
+// etag returns the quoted ETag for everything recorded
+func (v *versionSet) etag() string {
+	entries := append([]string(nil), v.entries...)
+	sort.Strings(entries)
+	return contentETag([]byte(strings.Join(entries, "\n")))
+}
+
+// contentETag returns a quoted ETag for a response body that has no
+// resourceVersions to derive one from
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
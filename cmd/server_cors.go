@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/cors"
+)
+
+func init() {
+	serverCmd.Flags().StringSliceVar(&cfg.Server.CORS.AllowedOrigins, "cors-allowed-origins", cfg.Server.CORS.AllowedOrigins, "Origins allowed to make cross-origin requests: exact (https://app.example.com), wildcard subdomains (https://*.example.com) or *")
+	serverCmd.Flags().StringSliceVar(&cfg.Server.CORS.AllowedMethods, "cors-allowed-methods", cfg.Server.CORS.AllowedMethods, "Methods allowed in cross-origin requests")
+	serverCmd.Flags().StringSliceVar(&cfg.Server.CORS.AllowedHeaders, "cors-allowed-headers", cfg.Server.CORS.AllowedHeaders, "Request headers allowed in cross-origin requests")
+	serverCmd.Flags().BoolVar(&cfg.Server.CORS.AllowCredentials, "cors-allow-credentials", cfg.Server.CORS.AllowCredentials, "Allow cross-origin requests to send cookies and Authorization headers")
+	serverCmd.Flags().DurationVar(&cfg.Server.CORS.MaxAge, "cors-max-age", cfg.Server.CORS.MaxAge, "How long browsers may cache preflight responses")
+}
+
+// newCORSPolicy builds the CORS policy from server flags
+func newCORSPolicy() (*cors.Policy, error) {
+	policy, err := cors.New(cfg.Server.CORS)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Rejected = func(ctx *fasthttp.RequestCtx, err error) {
+		log.WithContext(requestContext(ctx)).Warn("Cross-origin request rejected", map[string]interface{}{
+			"path":   string(ctx.Path()),
+			"origin": string(ctx.Request.Header.Peek("Origin")),
+			"reason": err.Error(),
+		})
+		sendErrorResponse(ctx, "Forbidden", err, fasthttp.StatusForbidden)
+	}
+	return policy, nil
+}
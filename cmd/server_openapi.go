@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/openapi"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/version"
+)
+
+const (
+	openAPIPath = "/api/v1/openapi.json"
+	docsPath    = "/docs"
+)
+
+// apiOperation documents one route. Every registered route needs an entry,
+// and every entry a route; the server refuses to start otherwise.
+type apiOperation struct {
+	summary string
+	tag     string
+	query   []openapi.Parameter
+	// data is the type of Response.Data on success, nil for no data
+	data interface{}
+	// contentType is set for routes that do not return the JSON envelope
+	contentType string
+	// public routes need no authentication and are not rate limited
+	public bool
+	// probe routes answer 500 with a text report when a check fails
+	probe  bool
+	list   bool
+	object bool
+	// etag routes answer If-None-Match with 304; list and object routes always do
+	etag bool
+}
+
+var (
+	namespaceQuery    = openapi.Parameter{Name: "namespace", In: "query", Description: "Namespace (default \"default\")", Schema: &openapi.Schema{Type: "string"}}
+	limitQuery        = openapi.Parameter{Name: "limit", In: "query", Description: "Maximum number of items per page", Schema: &openapi.Schema{Type: "integer", Format: "int64"}}
+	continueQuery     = openapi.Parameter{Name: "continue", In: "query", Description: "Token from metadata.continue of the previous page", Schema: &openapi.Schema{Type: "string"}}
+	ifNoneMatchHeader = openapi.Parameter{Name: "If-None-Match", In: "header", Description: "ETag of a cached response", Schema: &openapi.Schema{Type: "string"}}
+	probeQuery        = []openapi.Parameter{
+		{Name: "verbose", In: "query", Description: "List every check with the reason of failures", Schema: &openapi.Schema{Type: "boolean"}},
+		{Name: "exclude", In: "query", Description: "Name of a check to skip; repeatable", Schema: &openapi.Schema{Type: "string"}},
+	}
+	labelSelectorQuery = openapi.Parameter{Name: "labelSelector", In: "query", Description: "Label selector, e.g. app=web", Schema: &openapi.Schema{Type: "string"}}
+	ignoreStatusQuery  = openapi.Parameter{Name: "ignoreStatus", In: "query", Description: "Leave out changes to .status", Schema: &openapi.Schema{Type: "boolean"}}
+	alertStateQuery    = openapi.Parameter{Name: "state", In: "query", Description: "Only alerts in this state: pending, firing or resolved", Schema: &openapi.Schema{Type: "string"}}
+	timeoutQuery       = openapi.Parameter{Name: "timeout", In: "query", Description: "Deadline for collecting the status, e.g. 5s; at most the server's max-status-timeout", Schema: &openapi.Schema{Type: "string"}}
+)
+
+var apiOperations = map[string]apiOperation{
+	"GET /health":        {summary: "Server health", tag: "server", data: HealthStatus{}, public: true},
+	"GET /metrics":       {summary: "Prometheus metrics", tag: "server", contentType: "text/plain", public: true},
+	"GET /version":       {summary: "Version and build information", tag: "server", data: version.Info{}, public: true},
+	"GET /livez":         {summary: "Liveness probe", tag: "server", query: probeQuery, contentType: "text/plain", public: true, probe: true},
+	"GET /readyz":        {summary: "Readiness probe", tag: "server", query: probeQuery, contentType: "text/plain", public: true, probe: true},
+	"GET /startupz":      {summary: "Startup probe", tag: "server", query: probeQuery, contentType: "text/plain", public: true, probe: true},
+	"GET " + openAPIPath: {summary: "This OpenAPI document", tag: "server", contentType: "application/json", public: true},
+	"GET " + docsPath:    {summary: "API documentation page", tag: "server", contentType: "text/html", public: true},
+
+	"GET /api/v1/deployments":       {summary: "List deployment status", tag: "deployments", query: []openapi.Parameter{namespaceQuery, limitQuery, continueQuery}, data: DeploymentList{}, list: true},
+	"GET /api/v1/watch/deployments": {summary: "Stream deployment changes", tag: "deployments", query: []openapi.Parameter{namespaceQuery, labelSelectorQuery, ignoreStatusQuery}, data: DeploymentEvent{}, contentType: "text/event-stream"},
+	"GET /api/v1/events":            {summary: "List recent events", tag: "events", query: []openapi.Parameter{namespaceQuery, limitQuery, continueQuery}, data: EventList{}, list: true},
+	"GET /api/v1/alerts":            {summary: "List alerts of the alerting rules", tag: "alerts", query: []openapi.Parameter{namespaceQuery, alertStateQuery}, data: AlertList{}},
+	"GET /api/v1/status":            {summary: "Summarize a namespace", tag: "status", query: []openapi.Parameter{namespaceQuery, timeoutQuery}, data: NamespaceStatus{}, etag: true},
+
+	"GET /api/v1/namespaces/{namespace}/deployments":       {summary: "List deployment status", tag: "deployments", query: []openapi.Parameter{limitQuery, continueQuery}, data: DeploymentList{}, list: true},
+	"GET /api/v1/namespaces/{namespace}/watch/deployments": {summary: "Stream deployment changes", tag: "deployments", query: []openapi.Parameter{labelSelectorQuery, ignoreStatusQuery}, data: DeploymentEvent{}, contentType: "text/event-stream"},
+	"GET /api/v1/namespaces/{namespace}/events":            {summary: "List recent events", tag: "events", query: []openapi.Parameter{limitQuery, continueQuery}, data: EventList{}, list: true},
+	"GET /api/v1/namespaces/{namespace}/alerts":            {summary: "List alerts of the alerting rules", tag: "alerts", query: []openapi.Parameter{alertStateQuery}, data: AlertList{}},
+	"GET /api/v1/namespaces/{namespace}/status":            {summary: "Summarize a namespace", tag: "status", query: []openapi.Parameter{timeoutQuery}, data: NamespaceStatus{}, etag: true},
+
+	"GET /api/v1/namespaces/{namespace}/deployments/{name}": {summary: "Get a deployment", tag: "deployments", data: DeploymentDetail{}, object: true},
+	"GET /api/v1/namespaces/{namespace}/replicasets/{name}": {summary: "Get a replicaset", tag: "replicasets", data: ReplicaSetDetail{}, object: true},
+	"GET /api/v1/namespaces/{namespace}/pods/{name}":        {summary: "Get a pod", tag: "pods", data: PodDetail{}, object: true},
+	"GET /api/v1/namespaces/{namespace}/services/{name}":    {summary: "Get a service", tag: "services", data: ServiceDetail{}, object: true},
+	"GET /api/v1/namespaces/{namespace}/events/{name}":      {summary: "Get an event", tag: "events", data: EventDetail{}, object: true},
+}
+
+// buildOpenAPI generates the OpenAPI document for the registered routes from
+// apiOperations and the response types
+func buildOpenAPI(routes []router.Route) (*openapi.Document, error) {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "k8s-controller-tutorial API",
+		Description: "Deployment, event and namespace status of a Kubernetes cluster. See docs/API.md.",
+		Version:     "v1",
+	})
+	doc.Components.SecuritySchemes["bearerToken"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Static token or Kubernetes token checked with TokenReview, when authentication is enabled",
+	}
+	// Adds the envelope to the components, where operations reference it
+	doc.Schema(Response{})
+
+	for key, op := range apiOperations {
+		method, path, _ := strings.Cut(key, " ")
+		if err := doc.AddOperation(method, path, newOperation(doc, method, path, op)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := doc.Check(routes); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// newOperation describes a route with its success and error responses
+func newOperation(doc *openapi.Document, method, path string, op apiOperation) *openapi.Operation {
+	operation := &openapi.Operation{
+		Summary:     op.summary,
+		OperationID: operationID(method, path),
+		Tags:        []string{op.tag},
+		Parameters:  op.query,
+		Responses:   make(map[string]*openapi.Response),
+	}
+
+	if op.contentType != "" {
+		body := &openapi.Schema{Type: "string"}
+		if op.contentType == "application/json" {
+			body = &openapi.Schema{Type: "object"}
+		}
+		description := "OK"
+		if op.data != nil {
+			// Streams describe the JSON in the data field of each event
+			name := strings.TrimPrefix(doc.Schema(op.data).Ref, "#/components/schemas/")
+			description = fmt.Sprintf("Server-sent events; the data of each is a %s", name)
+		}
+		operation.Responses["200"] = &openapi.Response{
+			Description: description,
+			Content:     map[string]openapi.MediaType{op.contentType: {Schema: body}},
+		}
+		if op.probe {
+			operation.Responses["500"] = &openapi.Response{
+				Description: "A check failed",
+				Content:     map[string]openapi.MediaType{op.contentType: {Schema: body}},
+			}
+		}
+		if op.public {
+			return operation
+		}
+	} else {
+		success := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{}}
+		if op.data != nil {
+			success.Properties["data"] = doc.Schema(op.data)
+		}
+		operation.Responses["200"] = envelope("OK", openapi.Ref("Response"), success)
+	}
+
+	errorResponse := func(code int) {
+		operation.Responses[strconv.Itoa(code)] = envelope(http.StatusText(code), openapi.Ref("Response"))
+	}
+	if len(op.query) > 0 {
+		errorResponse(fasthttp.StatusBadRequest)
+	}
+	if op.list {
+		errorResponse(fasthttp.StatusGone)
+	}
+	if op.list || op.object || op.etag {
+		etag := "Strong ETag of the response; compressed responses append the encoding"
+		if op.object {
+			etag = "Quoted resourceVersion of the object; compressed responses append the encoding"
+		}
+		operation.Responses["200"].Headers = map[string]*openapi.Header{
+			"ETag":          {Description: etag, Schema: &openapi.Schema{Type: "string"}},
+			"Cache-Control": {Description: "private, no-cache: revalidate with If-None-Match", Schema: &openapi.Schema{Type: "string"}},
+		}
+		operation.Parameters = append(operation.Parameters, ifNoneMatchHeader)
+		operation.Responses["304"] = &openapi.Response{Description: "Not modified since the ETag in If-None-Match"}
+	}
+	if op.object {
+		errorResponse(fasthttp.StatusNotFound)
+	}
+	if !op.public {
+		operation.Security = []openapi.SecurityRequirement{{"bearerToken": {}}, {}}
+		errorResponse(fasthttp.StatusUnauthorized)
+		errorResponse(fasthttp.StatusForbidden)
+		errorResponse(fasthttp.StatusTooManyRequests)
+		operation.Responses["429"].Headers = map[string]*openapi.Header{
+			"Retry-After": {Description: "Seconds to wait before retrying", Schema: &openapi.Schema{Type: "integer"}},
+		}
+	}
+	errorResponse(fasthttp.StatusInternalServerError)
+	return operation
+}
+
+// envelope describes a JSON response made of all given schemas
+func envelope(description string, schemas ...*openapi.Schema) *openapi.Response {
+	schema := schemas[0]
+	if len(schemas) > 1 {
+		schema = &openapi.Schema{AllOf: schemas}
+	}
+	return &openapi.Response{
+		Description: description,
+		Content:     map[string]openapi.MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// operationID derives a stable identifier such as getNamespacesNamespaceDeploymentsName
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api/v1"), "/") {
+		segment = strings.Trim(segment, "{}")
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+// openAPIHandler serves the generated document and the documentation page
+type openAPIHandler struct {
+	spec []byte
+	page []byte
+}
+
+// load generates the document for routes
+func (h *openAPIHandler) load(routes []router.Route) error {
+	doc, err := buildOpenAPI(routes)
+	if err != nil {
+		return err
+	}
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %v", err)
+	}
+	page, err := openapi.DocsPage(doc.Info.Title, openAPIPath)
+	if err != nil {
+		return fmt.Errorf("failed to render documentation page: %v", err)
+	}
+
+	h.spec, h.page = spec, page
+	return nil
+}
+
+func (h *openAPIHandler) serveSpec(ctx *fasthttp.RequestCtx) {
+	ctx.SetBody(h.spec)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+func (h *openAPIHandler) serveDocs(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBody(h.page)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
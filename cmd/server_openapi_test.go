@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// newTestRouter builds the server's routes without a cluster
+func newTestRouter(t *testing.T) *router.Router {
+	t.Helper()
+	corsPolicy, err := newCORSPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := newRateLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newRouter(nil, nil, auth.AlwaysAllow{}, corsPolicy, limits, newProbes(), nil)
+	if err != nil {
+		t.Fatalf("failed to build the router: %v", err)
+	}
+	return r
+}
+
+// get sends a GET request to handler
+func get(handler fasthttp.RequestHandler, path string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI(path)
+	handler(ctx)
+	return ctx
+}
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	r := newTestRouter(t)
+	doc, err := buildOpenAPI(r.Routes())
+	if err != nil {
+		t.Fatalf("buildOpenAPI: %v", err)
+	}
+
+	for _, route := range r.Routes() {
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			t.Errorf("route %s %s is missing from the document", route.Method, route.Path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s %s has no operation", route.Method, route.Path)
+		}
+	}
+}
+
+func TestOpenAPIFailsOnMissingOperation(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, key := range []string{"GET /api/v1/alerts", "GET /api/v1/namespaces/{namespace}/pods/{name}", "GET " + docsPath} {
+		t.Run(key, func(t *testing.T) {
+			op := apiOperations[key]
+			delete(apiOperations, key)
+			defer func() { apiOperations[key] = op }()
+
+			_, err := buildOpenAPI(r.Routes())
+			if err == nil {
+				t.Fatalf("buildOpenAPI succeeded without an operation for %s", key)
+			}
+			_, path, _ := strings.Cut(key, " ")
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("error %q does not name %s", err, path)
+			}
+		})
+	}
+}
+
+func TestOpenAPIFailsOnOperationWithoutRoute(t *testing.T) {
+	r := newTestRouter(t)
+
+	key := "GET /api/v1/removed"
+	apiOperations[key] = apiOperation{summary: "Removed", tag: "server"}
+	defer delete(apiOperations, key)
+
+	if _, err := buildOpenAPI(r.Routes()); err == nil {
+		t.Fatalf("buildOpenAPI succeeded with an operation for a route that does not exist")
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	handler := newTestRouter(t).Handler()
+
+	ctx := get(handler, openAPIPath)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("GET %s = %d", openAPIPath, ctx.Response.StatusCode())
+	}
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(ctx.Response.Body(), &spec); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || len(spec.Paths) == 0 {
+		t.Errorf("unexpected document: openapi %q with %d paths", spec.OpenAPI, len(spec.Paths))
+	}
+
+	ctx = get(handler, docsPath)
+	page := string(ctx.Response.Body())
+	if ctx.Response.StatusCode() != fasthttp.StatusOK || !strings.Contains(page, "SwaggerUIBundle(") {
+		t.Fatalf("GET %s = %d without Swagger UI", docsPath, ctx.Response.StatusCode())
+	}
+	// The page must work without internet access
+	for _, external := range []string{`src="http`, `href="http`, "cdn."} {
+		if strings.Contains(page, external) {
+			t.Errorf("documentation page loads external resources (%s)", external)
+		}
+	}
+	if !strings.Contains(page, `"`+openAPIPath+`"`) {
+		t.Errorf("documentation page does not load %s", openAPIPath)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/config"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/ratelimit"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// Route groups with their own rate limits. Every /api request counts against
+// the api limits; /status requests, which fan out to several API server
+// calls, also count against the status limits.
+const (
+	rateLimitGroupAPI    = "api"
+	rateLimitGroupStatus = "status"
+)
+
+func init() {
+	serverCmd.Flags().StringToStringVar(&cfg.Server.RateLimits.PerIP, "rate-limit-per-ip", cfg.Server.RateLimits.PerIP, "Token bucket per client IP and route group (api, status) as RATE/UNIT[:BURST], or none")
+	serverCmd.Flags().StringToStringVar(&cfg.Server.RateLimits.PerUser, "rate-limit-per-user", cfg.Server.RateLimits.PerUser, "Token bucket per authenticated user and route group (api, status) as RATE/UNIT[:BURST], or none")
+	serverCmd.Flags().IntVar(&cfg.Server.RateLimits.MaxInFlight, "max-in-flight", cfg.Server.RateLimits.MaxInFlight, "Maximum number of /api requests handled at the same time (0 disables)")
+}
+
+// rateLimits holds the limiters for all route groups
+type rateLimits struct {
+	perIP       map[string]*ratelimit.Limiter
+	perUser     map[string]*ratelimit.Limiter
+	inFlight    *ratelimit.InFlight
+	maxInFlight int
+}
+
+// newRateLimits builds the limiters from server flags
+func newRateLimits() (*rateLimits, error) {
+	perIP, err := parseRateLimits("--rate-limit-per-ip", cfg.Server.RateLimits.PerIP)
+	if err != nil {
+		return nil, err
+	}
+	perUser, err := parseRateLimits("--rate-limit-per-user", cfg.Server.RateLimits.PerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rateLimits{
+		perIP:       perIP,
+		perUser:     perUser,
+		inFlight:    ratelimit.NewInFlight(cfg.Server.RateLimits.MaxInFlight),
+		maxInFlight: cfg.Server.RateLimits.MaxInFlight,
+	}, nil
+}
+
+// update applies reloaded limits to the running limiters. Nothing changes
+// unless all limits parse.
+func (l *rateLimits) update(c config.RateLimitConfig) error {
+	perIP, err := parseRateLimits("server.rateLimits.perIP", c.PerIP)
+	if err != nil {
+		return err
+	}
+	perUser, err := parseRateLimits("server.rateLimits.perUser", c.PerUser)
+	if err != nil {
+		return err
+	}
+
+	for group, limiter := range perIP {
+		l.perIP[group].SetLimit(limiter.Limit())
+	}
+	for group, limiter := range perUser {
+		l.perUser[group].SetLimit(limiter.Limit())
+	}
+	l.inFlight.SetMax(c.MaxInFlight)
+	l.maxInFlight = c.MaxInFlight
+
+	l.logConfig()
+	return nil
+}
+
+// parseRateLimits parses group=limit pairs; groups that are not given keep
+// their default limit
+func parseRateLimits(flag string, specs map[string]string) (map[string]*ratelimit.Limiter, error) {
+	for group := range specs {
+		if _, ok := config.DefaultRateLimits[group]; !ok {
+			return nil, fmt.Errorf("%s: unknown route group %q (expected %s or %s)", flag, group, rateLimitGroupAPI, rateLimitGroupStatus)
+		}
+	}
+
+	limiters := make(map[string]*ratelimit.Limiter)
+	for group, spec := range config.DefaultRateLimits {
+		if s, ok := specs[group]; ok {
+			spec = s
+		}
+		limit, err := ratelimit.ParseLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", flag, err)
+		}
+		limiters[group] = ratelimit.NewLimiter(limit)
+	}
+	return limiters, nil
+}
+
+// logConfig logs the effective limits
+func (l *rateLimits) logConfig() {
+	fields := map[string]interface{}{"max_in_flight": l.maxInFlight}
+	for _, limiters := range []struct {
+		scope   string
+		byGroup map[string]*ratelimit.Limiter
+	}{{"ip", l.perIP}, {"user", l.perUser}} {
+		groups := make([]string, 0, len(limiters.byGroup))
+		for group := range limiters.byGroup {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			fields[limiters.scope+"_"+group] = limiters.byGroup[group].Limit().String()
+		}
+	}
+	log.Info("Rate limits configured", fields)
+}
+
+// concurrency caps the number of requests in flight
+func (l *rateLimits) concurrency() router.Middleware {
+	return l.inFlight.Middleware(rejectLimited(rateLimitGroupAPI))
+}
+
+// byIP limits a route group per client IP; it runs before authentication so
+// unauthenticated floods are limited too
+func (l *rateLimits) byIP(group string) router.Middleware {
+	return l.perIP[group].Middleware("ip", ratelimit.ClientIP, rejectLimited(group))
+}
+
+// byUser limits a route group per authenticated user; it must run after
+// authentication and does nothing when authentication is disabled
+func (l *rateLimits) byUser(group string) router.Middleware {
+	return l.perUser[group].Middleware("user", func(ctx *fasthttp.RequestCtx) string {
+		if user := requestUser(ctx); user != nil {
+			return user.Name
+		}
+		return ""
+	}, rejectLimited(group))
+}
+
+// rejectLimited answers limited requests with 429 in the standard envelope
+func rejectLimited(group string) ratelimit.RejectFunc {
+	return func(ctx *fasthttp.RequestCtx, rejection *ratelimit.Rejection) {
+		metrics.RequestsRejected.WithLabelValues(group, rejection.Reason).Inc()
+		log.WithContext(requestContext(ctx)).Warn("Request rate limited", map[string]interface{}{
+			"path":   string(ctx.Path()),
+			"remote": ctx.RemoteIP().String(),
+			"group":  group,
+			"reason": rejection.Reason,
+		})
+		sendErrorResponse(ctx, "Too Many Requests", rejection, fasthttp.StatusTooManyRequests)
+	}
+}
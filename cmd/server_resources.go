@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// revisionAnnotation holds the rollout revision of deployments and replicasets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// Condition represents a status condition of a Kubernetes object
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"last_transition_time,omitempty"`
+}
+
+// Container summarizes a container in a pod template or pod
+type Container struct {
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	Ready        *bool  `json:"ready,omitempty"`
+	RestartCount *int32 `json:"restart_count,omitempty"`
+	State        string `json:"state,omitempty"`
+}
+
+// DeploymentStrategy summarizes a deployment's rollout strategy
+type DeploymentStrategy struct {
+	Type           string `json:"type"`
+	MaxSurge       string `json:"max_surge,omitempty"`
+	MaxUnavailable string `json:"max_unavailable,omitempty"`
+}
+
+// DeploymentDetail is the full status and spec summary of a deployment
+type DeploymentDetail struct {
+	DeploymentStatus
+	ResourceVersion string             `json:"resource_version"`
+	Revision        string             `json:"revision,omitempty"`
+	Selector        string             `json:"selector"`
+	Strategy        DeploymentStrategy `json:"strategy"`
+	Containers      []Container        `json:"containers"`
+	Conditions      []Condition        `json:"conditions"`
+	Labels          map[string]string  `json:"labels,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// ReplicaSetDetail summarizes a replicaset
+type ReplicaSetDetail struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	ResourceVersion   string            `json:"resource_version"`
+	Owner             string            `json:"owner,omitempty"`
+	Revision          string            `json:"revision,omitempty"`
+	Selector          string            `json:"selector"`
+	DesiredReplicas   int32             `json:"desired_replicas"`
+	ReadyReplicas     int32             `json:"ready_replicas"`
+	AvailableReplicas int32             `json:"available_replicas"`
+	Containers        []Container       `json:"containers"`
+	Conditions        []Condition       `json:"conditions"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+// PodDetail summarizes a pod
+type PodDetail struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resource_version"`
+	Phase           string            `json:"phase"`
+	Node            string            `json:"node,omitempty"`
+	PodIP           string            `json:"pod_ip,omitempty"`
+	Owner           string            `json:"owner,omitempty"`
+	Containers      []Container       `json:"containers"`
+	Conditions      []Condition       `json:"conditions"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	StartTime       *time.Time        `json:"start_time,omitempty"`
+}
+
+// ServicePort summarizes a service port
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol"`
+	Port       int32  `json:"port"`
+	TargetPort string `json:"target_port"`
+	NodePort   int32  `json:"node_port,omitempty"`
+}
+
+// ServiceDetail summarizes a service
+type ServiceDetail struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resource_version"`
+	Type            string            `json:"type"`
+	ClusterIP       string            `json:"cluster_ip,omitempty"`
+	ExternalIPs     []string          `json:"external_ips,omitempty"`
+	LoadBalancer    []string          `json:"load_balancer,omitempty"`
+	Ports           []ServicePort     `json:"ports"`
+	Selector        map[string]string `json:"selector,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// EventDetail is a single Kubernetes event
+type EventDetail struct {
+	Event
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resource_version"`
+	Kind            string `json:"kind"`
+	Count           int32  `json:"count"`
+	Source          string `json:"source,omitempty"`
+}
+
+// resourceGetter fetches one object and returns its resourceVersion and response data
+type resourceGetter func(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error)
+
+// handleGetResource serves a single object. It returns 404 when the object is
+// missing and honours If-None-Match against the object's resourceVersion.
+func handleGetResource(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset, kind string, get resourceGetter) {
+	namespace := requestNamespace(ctx)
+	name := router.Param(ctx, "name")
+
+	reqCtx := requestContext(ctx)
+	namespaceLogger := log.WithContext(reqCtx).WithNamespace(namespace)
+	namespaceLogger.Info("HTTP request: Get "+kind, map[string]interface{}{
+		"kind": kind,
+		"name": name,
+	})
+
+	resourceVersion, data, err := get(reqCtx, clientset, namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			err = &NotFoundError{Kind: kind, Namespace: namespace, Name: name}
+		}
+		namespaceLogger.Error("Failed to get "+kind, err, map[string]interface{}{
+			"name": name,
+		})
+		sendErrorResponse(ctx, "Failed to get "+kind, err, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if notModified(ctx, `"`+resourceVersion+`"`) {
+		return
+	}
+
+	response := Response{
+		Success: true,
+		Data:    data,
+	}
+
+	jsonResponse, _ := json.Marshal(response)
+	ctx.SetBody(jsonResponse)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+func getDeploymentDetail(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	strategy := DeploymentStrategy{Type: string(deployment.Spec.Strategy.Type)}
+	if rollingUpdate := deployment.Spec.Strategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.MaxSurge != nil {
+			strategy.MaxSurge = rollingUpdate.MaxSurge.String()
+		}
+		if rollingUpdate.MaxUnavailable != nil {
+			strategy.MaxUnavailable = rollingUpdate.MaxUnavailable.String()
+		}
+	}
+
+	conditions := toConditions(deployment.Status.Conditions, func(c appsv1.DeploymentCondition) Condition {
+		return newCondition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime)
+	})
+
+	return deployment.ResourceVersion, DeploymentDetail{
+		DeploymentStatus: newDeploymentStatus(deployment),
+		ResourceVersion:  deployment.ResourceVersion,
+		Revision:         deployment.Annotations[revisionAnnotation],
+		Selector:         selectorString(deployment.Spec.Selector),
+		Strategy:         strategy,
+		Containers:       templateContainers(deployment.Spec.Template.Spec),
+		Conditions:       conditions,
+		Labels:           deployment.Labels,
+		CreatedAt:        deployment.CreationTimestamp.Time,
+	}, nil
+}
+
+func getReplicaSetDetail(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error) {
+	replicaSet, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	desiredReplicas := int32(1)
+	if replicaSet.Spec.Replicas != nil {
+		desiredReplicas = *replicaSet.Spec.Replicas
+	}
+
+	conditions := toConditions(replicaSet.Status.Conditions, func(c appsv1.ReplicaSetCondition) Condition {
+		return newCondition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime)
+	})
+
+	return replicaSet.ResourceVersion, ReplicaSetDetail{
+		Name:              replicaSet.Name,
+		Namespace:         replicaSet.Namespace,
+		ResourceVersion:   replicaSet.ResourceVersion,
+		Owner:             ownerName(replicaSet.OwnerReferences),
+		Revision:          replicaSet.Annotations[revisionAnnotation],
+		Selector:          selectorString(replicaSet.Spec.Selector),
+		DesiredReplicas:   desiredReplicas,
+		ReadyReplicas:     replicaSet.Status.ReadyReplicas,
+		AvailableReplicas: replicaSet.Status.AvailableReplicas,
+		Containers:        templateContainers(replicaSet.Spec.Template.Spec),
+		Conditions:        conditions,
+		Labels:            replicaSet.Labels,
+		CreatedAt:         replicaSet.CreationTimestamp.Time,
+	}, nil
+}
+
+func getPodDetail(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	containers := make([]Container, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		summary := Container{Name: container.Name, Image: container.Image}
+		if status, ok := statuses[container.Name]; ok {
+			ready, restarts := status.Ready, status.RestartCount
+			summary.Ready = &ready
+			summary.RestartCount = &restarts
+			summary.State = containerState(status.State)
+		}
+		containers = append(containers, summary)
+	}
+
+	conditions := toConditions(pod.Status.Conditions, func(c corev1.PodCondition) Condition {
+		return newCondition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime)
+	})
+
+	detail := PodDetail{
+		Name:            pod.Name,
+		Namespace:       pod.Namespace,
+		ResourceVersion: pod.ResourceVersion,
+		Phase:           string(pod.Status.Phase),
+		Node:            pod.Spec.NodeName,
+		PodIP:           pod.Status.PodIP,
+		Owner:           ownerName(pod.OwnerReferences),
+		Containers:      containers,
+		Conditions:      conditions,
+		Labels:          pod.Labels,
+	}
+	if pod.Status.StartTime != nil {
+		detail.StartTime = &pod.Status.StartTime.Time
+	}
+
+	return pod.ResourceVersion, detail, nil
+}
+
+func getServiceDetail(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error) {
+	service, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	ports := make([]ServicePort, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, ServicePort{
+			Name:       port.Name,
+			Protocol:   string(port.Protocol),
+			Port:       port.Port,
+			TargetPort: port.TargetPort.String(),
+			NodePort:   port.NodePort,
+		})
+	}
+
+	var loadBalancer []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			loadBalancer = append(loadBalancer, ingress.Hostname)
+		} else {
+			loadBalancer = append(loadBalancer, ingress.IP)
+		}
+	}
+
+	return service.ResourceVersion, ServiceDetail{
+		Name:            service.Name,
+		Namespace:       service.Namespace,
+		ResourceVersion: service.ResourceVersion,
+		Type:            string(service.Spec.Type),
+		ClusterIP:       service.Spec.ClusterIP,
+		ExternalIPs:     service.Spec.ExternalIPs,
+		LoadBalancer:    loadBalancer,
+		Ports:           ports,
+		Selector:        service.Spec.Selector,
+		Labels:          service.Labels,
+		CreatedAt:       service.CreationTimestamp.Time,
+	}, nil
+}
+
+func getEventDetail(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (string, interface{}, error) {
+	event, err := clientset.CoreV1().Events(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return event.ResourceVersion, EventDetail{
+		Event: Event{
+			Type:      event.Type,
+			Reason:    event.Reason,
+			Message:   event.Message,
+			Timestamp: event.LastTimestamp.Time,
+			Object:    event.InvolvedObject.Name,
+		},
+		Name:            event.Name,
+		Namespace:       event.Namespace,
+		ResourceVersion: event.ResourceVersion,
+		Kind:            event.InvolvedObject.Kind,
+		Count:           event.Count,
+		Source:          event.Source.Component,
+	}, nil
+}
+
+// templateContainers summarizes the containers of a pod template
+func templateContainers(spec corev1.PodSpec) []Container {
+	containers := make([]Container, 0, len(spec.Containers))
+	for _, container := range spec.Containers {
+		containers = append(containers, Container{Name: container.Name, Image: container.Image})
+	}
+	return containers
+}
+
+// containerState returns a short description of a container's state
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "running"
+	case state.Waiting != nil:
+		return "waiting: " + state.Waiting.Reason
+	case state.Terminated != nil:
+		return fmt.Sprintf("terminated: %s (exit code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	}
+	return ""
+}
+
+// selectorString formats a label selector the way kubectl does
+func selectorString(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+	return metav1.FormatLabelSelector(selector)
+}
+
+// ownerName returns "Kind/name" of the controlling owner, if any
+// toConditions converts the status conditions of an object with get
+func toConditions[T any](items []T, get func(T) Condition) []Condition {
+	conditions := make([]Condition, 0, len(items))
+	for _, item := range items {
+		conditions = append(conditions, get(item))
+	}
+	return conditions
+}
+
+func newCondition(conditionType, status, reason, message string, lastTransition metav1.Time) Condition {
+	return Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: lastTransition.Time,
+	}
+}
+
+func ownerName(owners []metav1.OwnerReference) string {
+	for _, owner := range owners {
+		if owner.Controller != nil && *owner.Controller {
+			return owner.Kind + "/" + owner.Name
+		}
+	}
+	return ""
+}
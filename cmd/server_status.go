@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+)
+
+// NamespaceStatus summarizes the workloads in a namespace. Sections that
+// could not be fetched are omitted and reported as warnings.
+type NamespaceStatus struct {
+	Namespace              NamespaceInfo    `json:"namespace"`
+	Deployments            *WorkloadSummary `json:"deployments,omitempty"`
+	StatefulSets           *WorkloadSummary `json:"statefulsets,omitempty"`
+	DaemonSets             *WorkloadSummary `json:"daemonsets,omitempty"`
+	Jobs                   *JobSummary      `json:"jobs,omitempty"`
+	Pods                   *PhaseSummary    `json:"pods,omitempty"`
+	Services               *CountSummary    `json:"services,omitempty"`
+	PersistentVolumeClaims *PhaseSummary    `json:"persistentvolumeclaims,omitempty"`
+	Timestamp              time.Time        `json:"timestamp"`
+}
+
+// NamespaceInfo identifies the summarized namespace
+type NamespaceInfo struct {
+	Name string `json:"name"`
+}
+
+// WorkloadSummary counts workloads by readiness
+type WorkloadSummary struct {
+	Total     int `json:"total"`
+	Healthy   int `json:"healthy"`
+	Unhealthy int `json:"unhealthy"`
+}
+
+// JobSummary counts jobs by outcome
+type JobSummary struct {
+	Total     int `json:"total"`
+	Active    int `json:"active"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// PhaseSummary counts objects by phase
+type PhaseSummary struct {
+	Total  int              `json:"total"`
+	Status map[string]int32 `json:"status"`
+}
+
+// CountSummary counts objects
+type CountSummary struct {
+	Total int `json:"total"`
+}
+
+// statusSection fetches and summarizes one resource type for /api/v1/status.
+// Each section sets its own field of the status, so sections run concurrently.
+type statusSection struct {
+	name  string
+	group string
+	fetch func(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error
+}
+
+// statusSections are fetched concurrently for every status request. The name
+// is also the resource the caller needs list access to.
+var statusSections = []statusSection{
+	{name: "deployments", group: "apps", fetch: deploymentsSummary},
+	{name: "statefulsets", group: "apps", fetch: statefulSetsSummary},
+	{name: "daemonsets", group: "apps", fetch: daemonSetsSummary},
+	{name: "jobs", group: "batch", fetch: jobsSummary},
+	{name: "pods", group: "", fetch: podsSummary},
+	{name: "services", group: "", fetch: servicesSummary},
+	{name: "persistentvolumeclaims", group: "", fetch: persistentVolumeClaimsSummary},
+}
+
+// statusTimeout returns the timeout query parameter, which must not exceed
+// the configured maximum, or the default deadline
+func statusTimeout(ctx *fasthttp.RequestCtx) (time.Duration, error) {
+	timeoutStr := string(ctx.QueryArgs().Peek("timeout"))
+	if timeoutStr == "" {
+		return cfg.Server.StatusTimeout, nil
+	}
+	d, err := time.ParseDuration(timeoutStr)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("timeout must be a positive duration such as 5s: %q", timeoutStr)
+	}
+	if d > cfg.Server.MaxStatusTimeout {
+		return 0, fmt.Errorf("timeout must not exceed %s: %q", cfg.Server.MaxStatusTimeout, timeoutStr)
+	}
+	return d, nil
+}
+
+// handleGetStatus summarizes a namespace. Resource types the caller may not
+// list are reported as warnings; only if all are forbidden the request fails with 403.
+func handleGetStatus(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset, authorizer auth.Authorizer) {
+	namespace := requestNamespace(ctx)
+
+	timeout, err := statusTimeout(ctx)
+	if err != nil {
+		sendErrorResponse(ctx, "Invalid timeout", err, fasthttp.StatusBadRequest)
+		return
+	}
+
+	reqCtx := requestContext(ctx)
+	namespaceLogger := log.WithContext(reqCtx).WithNamespace(namespace)
+	namespaceLogger.Info("HTTP request: Get cluster status", map[string]interface{}{
+		"namespace": namespace,
+		"timeout":   timeout.String(),
+	})
+
+	listCtx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		warnings  []string
+		forbidden int
+		timedOut  int
+	)
+	status := &NamespaceStatus{Namespace: NamespaceInfo{Name: namespace}}
+
+	for _, section := range statusSections {
+		wg.Add(1)
+		go func(section statusSection) {
+			defer wg.Done()
+
+			err := checkAccess(ctx, authorizer, auth.Attributes{
+				Verb:      "list",
+				Group:     section.group,
+				Resource:  section.name,
+				Namespace: namespace,
+			})
+			if err == nil {
+				err = section.fetch(listCtx, clientset, namespace, status)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if errorStatusCode(err, 0) == fasthttp.StatusForbidden {
+				forbidden++
+			}
+			if err != nil {
+				// Warnings are returned to the client, the error only logged
+				warning := publicErrorMessage(err, fasthttp.StatusInternalServerError)
+				// The client-side rate limiter reports a deadline it cannot meet
+				// with its own error, so the context is checked too
+				if errors.Is(err, context.DeadlineExceeded) || errors.Is(listCtx.Err(), context.DeadlineExceeded) {
+					timedOut++
+					warning = fmt.Sprintf("timed out after %s", timeout)
+				}
+				namespaceLogger.Warn("Failed to get resources for status", map[string]interface{}{
+					"resource": section.name,
+					"error":    err.Error(),
+				})
+				warnings = append(warnings, fmt.Sprintf("%s: %s", section.name, warning))
+			}
+		}(section)
+	}
+	wg.Wait()
+
+	sort.Strings(warnings)
+	if forbidden == len(statusSections) {
+		sendErrorResponse(ctx, "Forbidden", errors.New(strings.Join(warnings, "; ")), fasthttp.StatusForbidden)
+		return
+	}
+	if len(warnings) == len(statusSections) {
+		namespaceLogger.Error("Failed to get cluster status", nil, map[string]interface{}{
+			"warnings": warnings,
+		})
+		statusCode := fasthttp.StatusInternalServerError
+		if timedOut == len(statusSections) {
+			statusCode = fasthttp.StatusGatewayTimeout
+		}
+		sendErrorResponse(ctx, "Failed to get cluster status", errors.New(strings.Join(warnings, "; ")), statusCode)
+		return
+	}
+
+	response := Response{
+		Success:  true,
+		Data:     status,
+		Warnings: warnings,
+	}
+
+	// The summary only holds counts, so its ETag is a hash of the response
+	// before the timestamp is set
+	unstamped, _ := json.Marshal(response)
+	if notModified(ctx, contentETag(unstamped)) {
+		return
+	}
+
+	status.Timestamp = time.Now().UTC()
+	jsonResponse, _ := json.Marshal(response)
+	ctx.SetBody(jsonResponse)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+func deploymentsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	healthy := 0
+	for i := range deployments.Items {
+		if newDeploymentStatus(&deployments.Items[i]).Healthy {
+			healthy++
+		}
+	}
+
+	status.Deployments = &WorkloadSummary{
+		Total:     len(deployments.Items),
+		Healthy:   healthy,
+		Unhealthy: len(deployments.Items) - healthy,
+	}
+	return nil
+}
+
+func statefulSetsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	healthy := 0
+	for _, statefulSet := range statefulSets.Items {
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Status.ReadyReplicas >= desired {
+			healthy++
+		}
+	}
+
+	status.StatefulSets = &WorkloadSummary{
+		Total:     len(statefulSets.Items),
+		Healthy:   healthy,
+		Unhealthy: len(statefulSets.Items) - healthy,
+	}
+	return nil
+}
+
+func daemonSetsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	healthy := 0
+	for _, daemonSet := range daemonSets.Items {
+		if daemonSet.Status.NumberReady >= daemonSet.Status.DesiredNumberScheduled {
+			healthy++
+		}
+	}
+
+	status.DaemonSets = &WorkloadSummary{
+		Total:     len(daemonSets.Items),
+		Healthy:   healthy,
+		Unhealthy: len(daemonSets.Items) - healthy,
+	}
+	return nil
+}
+
+func jobsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	succeeded, failed := 0, 0
+	for _, job := range jobs.Items {
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				succeeded++
+			case batchv1.JobFailed:
+				failed++
+			}
+		}
+	}
+
+	status.Jobs = &JobSummary{
+		Total:     len(jobs.Items),
+		Active:    len(jobs.Items) - succeeded - failed,
+		Succeeded: succeeded,
+		Failed:    failed,
+	}
+	return nil
+}
+
+func podsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	podStatus := make(map[string]int32)
+	for _, pod := range pods.Items {
+		podStatus[string(pod.Status.Phase)]++
+	}
+
+	status.Pods = &PhaseSummary{
+		Total:  len(pods.Items),
+		Status: podStatus,
+	}
+	return nil
+}
+
+func servicesSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	status.Services = &CountSummary{Total: len(services.Items)}
+	return nil
+}
+
+func persistentVolumeClaimsSummary(ctx context.Context, clientset *kubernetes.Clientset, namespace string, status *NamespaceStatus) error {
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	claimStatus := make(map[string]int32)
+	for _, claim := range claims.Items {
+		claimStatus[string(claim.Status.Phase)]++
+	}
+
+	status.PersistentVolumeClaims = &PhaseSummary{
+		Total:  len(claims.Items),
+		Status: claimStatus,
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestStatusTimeout(t *testing.T) {
+	saved := cfg.Server
+	defer func() { cfg.Server = saved }()
+	cfg.Server.StatusTimeout = 10 * time.Second
+	cfg.Server.MaxStatusTimeout = time.Minute
+
+	tests := []struct {
+		query string
+		want  time.Duration
+		err   string
+	}{
+		{query: "", want: 10 * time.Second},
+		{query: "timeout=3s", want: 3 * time.Second},
+		{query: "timeout=1m", want: time.Minute},
+		{query: "timeout=61s", err: "must not exceed 1m0s"},
+		{query: "timeout=876000h", err: "must not exceed 1m0s"},
+		{query: "timeout=0s", err: "positive duration"},
+		{query: "timeout=-1s", err: "positive duration"},
+		{query: "timeout=5", err: "positive duration"},
+	}
+	for _, tt := range tests {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/api/v1/status?" + tt.query)
+		got, err := statusTimeout(ctx)
+		switch {
+		case tt.err == "" && (err != nil || got != tt.want):
+			t.Errorf("%q: statusTimeout() = %s, %v, want %s", tt.query, got, err, tt.want)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%q: statusTimeout() = %s, %v, want an error containing %q", tt.query, got, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/certs"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/metrics"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// selfSignedValidity is how long a generated development certificate is valid
+const selfSignedValidity = 365 * 24 * time.Hour
+
+func init() {
+	serverCmd.Flags().StringVar(&cfg.Server.TLS.CertFile, "tls-cert-file", cfg.Server.TLS.CertFile, "PEM certificate for HTTPS; reloaded when the file changes")
+	serverCmd.Flags().StringVar(&cfg.Server.TLS.PrivateKeyFile, "tls-private-key-file", cfg.Server.TLS.PrivateKeyFile, "PEM private key matching --tls-cert-file")
+	serverCmd.Flags().StringVar(&cfg.Server.TLS.MinVersion, "tls-min-version", cfg.Server.TLS.MinVersion, "Minimum TLS version: VersionTLS12 or VersionTLS13")
+	serverCmd.Flags().StringVar(&cfg.Server.TLS.ClientCAFile, "client-ca-file", cfg.Server.TLS.ClientCAFile, "PEM CA bundle used to verify client certificates (enables client certificate authentication)")
+	serverCmd.Flags().BoolVar(&cfg.Server.TLS.SelfSigned, "tls-self-signed", cfg.Server.TLS.SelfSigned, "Serve HTTPS with a generated self-signed certificate (development only)")
+	serverCmd.Flags().IntVar(&cfg.Server.HTTPRedirectPort, "http-redirect-port", cfg.Server.HTTPRedirectPort, "Plain HTTP port that redirects to HTTPS (0 disables)")
+	serverCmd.Flags().IntVar(&cfg.Server.HealthPort, "health-port", cfg.Server.HealthPort, "Separate plain HTTP port serving only /health, /version, /livez, /readyz, /startupz and /metrics (0 disables)")
+}
+
+// newTLSConfig loads the serving certificate and starts watching the
+// certificate files, so rotated certificates are used without a restart
+func newTLSConfig(ctx context.Context) (*tls.Config, error) {
+	minVersion, err := certs.ParseTLSVersion(cfg.Server.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader, err := certs.NewReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.PrivateKeyFile, cfg.Server.TLS.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Server.TLS.SelfSigned {
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if cfg.Server.Host != "" && cfg.Server.Host != "0.0.0.0" && cfg.Server.Host != "::" {
+			hosts = append(hosts, cfg.Server.Host)
+		}
+		certificate, err := certs.GenerateSelfSigned(hosts, selfSignedValidity)
+		if err != nil {
+			return nil, err
+		}
+		reloader.SetCertificate(certificate)
+		log.Warn("Serving with a self-signed certificate; do not use this in production", map[string]interface{}{
+			"hosts": hosts,
+		})
+	}
+
+	if cfg.Server.TLS.CertFile != "" || cfg.Server.TLS.ClientCAFile != "" {
+		err := reloader.Watch(ctx, func(err error) {
+			if err != nil {
+				log.Error("Failed to reload TLS certificates; keeping the previous ones", err, nil)
+				return
+			}
+			log.Info("Reloaded TLS certificates", map[string]interface{}{
+				"cert_file":      cfg.Server.TLS.CertFile,
+				"client_ca_file": cfg.Server.TLS.ClientCAFile,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reloader.TLSConfig(minVersion), nil
+}
+
+// serveHTTPRedirect redirects plain HTTP requests to the HTTPS server
+func serveHTTPRedirect(port int) {
+	redirect := func(ctx *fasthttp.RequestCtx) {
+		host := string(ctx.Host())
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		target := "https://" + net.JoinHostPort(host, strconv.Itoa(cfg.Server.Port)) + string(ctx.RequestURI())
+
+		status := fasthttp.StatusPermanentRedirect
+		if ctx.IsGet() || ctx.IsHead() {
+			status = fasthttp.StatusMovedPermanently
+		}
+		ctx.Redirect(target, status)
+	}
+
+	serveAuxiliary("HTTP redirect", cfg.Server.Host, port, redirect)
+}
+
+// serveHealthPort serves /health, /version, the probes and /metrics without
+// TLS or authentication, for the kubelet and Prometheus scrapes
+func serveHealthPort(host string, port int, probes *probes) {
+	r := router.New()
+	r.NotFound = handleNotFound
+	r.MethodNotAllowed = handleMethodNotAllowed
+	r.Use(commonHeadersMiddleware)
+	r.GET("/health", handleHealth)
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/version", handleVersion)
+	probes.register(r)
+
+	serveAuxiliary("health", host, port, r.Handler())
+}
+
+// serveAuxiliary runs a plain HTTP server next to the main work of a command
+func serveAuxiliary(name, host string, port int, handler fasthttp.RequestHandler) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	server := &fasthttp.Server{
+		Handler: handler,
+		Name:    "k8s-controller-server",
+	}
+
+	go func() {
+		log.Info("Starting "+name+" server", map[string]interface{}{
+			"address": addr,
+		})
+		if err := server.ListenAndServe(addr); err != nil {
+			log.Fatal("Server error", err, map[string]interface{}{
+				"server":  name,
+				"address": addr,
+			})
+		}
+	}()
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/diff"
+)
+
+// watchHeartbeat is how often an idle stream sends a comment, which keeps
+// proxies from closing it and detects clients that went away
+const watchHeartbeat = 15 * time.Second
+
+// DeploymentEvent is the data of one server-sent event of the deployment watch
+type DeploymentEvent struct {
+	// Type is ADDED, MODIFIED or DELETED
+	Type            string           `json:"type"`
+	Namespace       string           `json:"namespace"`
+	Name            string           `json:"name"`
+	ResourceVersion string           `json:"resource_version"`
+	Deployment      DeploymentStatus `json:"deployment"`
+	// Changes and Patch are set for MODIFIED; the patch is an RFC 6902 JSON
+	// Patch from the previous to the new version of the changed fields
+	Changes []diff.Change    `json:"changes,omitempty"`
+	Patch   []diff.Operation `json:"patch,omitempty"`
+}
+
+// watchFailure is a failure of the watch behind a stream
+type watchFailure struct {
+	err error
+	// final is set when the watch gives up
+	final bool
+}
+
+// retryableWatchError reports whether a watch failure may go away by itself.
+// A namespace or selector the API server rejects, or missing permissions, do
+// not.
+func retryableWatchError(err error) bool {
+	switch {
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err), apierrors.IsNotFound(err),
+		apierrors.IsInvalid(err), apierrors.IsBadRequest(err), apierrors.IsMethodNotSupported(err):
+		return false
+	}
+	return true
+}
+
+// handleWatchDeployments streams the deployments of a namespace as
+// server-sent events: every deployment as ADDED, then its changes. A
+// MODIFIED event is only sent when a meaningful field changed; with
+// ignoreStatus=true changes to .status are left out as well. Watch failures
+// are sent as error events; the stream ends after one that is not retried.
+func handleWatchDeployments(ctx *fasthttp.RequestCtx, clientset *kubernetes.Clientset) {
+	namespace := requestNamespace(ctx)
+	args := ctx.QueryArgs()
+
+	var opts diff.Options
+	if value := args.Peek("ignoreStatus"); len(value) > 0 {
+		ignore, err := strconv.ParseBool(string(value))
+		if err != nil {
+			sendErrorResponse(ctx, "Invalid watch parameters", fmt.Errorf("invalid ignoreStatus %q: %v", value, err), fasthttp.StatusBadRequest)
+			return
+		}
+		opts.IgnoreStatus = ignore
+	}
+	selector := string(args.Peek("labelSelector"))
+	if _, err := labels.Parse(selector); err != nil {
+		sendErrorResponse(ctx, "Invalid watch parameters", fmt.Errorf("invalid labelSelector: %v", err), fasthttp.StatusBadRequest)
+		return
+	}
+
+	reqCtx := requestContext(ctx)
+	namespaceLogger := log.WithContext(reqCtx).WithNamespace(namespace)
+	namespaceLogger.Info("HTTP request: Watch deployments", map[string]interface{}{
+		"namespace":      namespace,
+		"label_selector": selector,
+		"ignore_status":  opts.IgnoreStatus,
+	})
+
+	// Problems found before the stream starts get an error status, which
+	// stops EventSource clients from reconnecting
+	_, err := clientset.AppsV1().Deployments(namespace).List(reqCtx, metav1.ListOptions{LabelSelector: selector, Limit: 1})
+	if err != nil {
+		namespaceLogger.Error("Failed to watch deployments", err, nil)
+		sendErrorResponse(ctx, "Failed to watch deployments", err, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+
+	// The stream outlives the handler and its request context; it ends when
+	// a write fails because the client went away
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events := make(chan DeploymentEvent)
+		failures := make(chan watchFailure)
+		watchOpts := watchOptions{
+			watcher: watcherAPI,
+			failed: func(err error) bool {
+				failure := watchFailure{err: err, final: !retryableWatchError(err)}
+				select {
+				case failures <- failure:
+				case <-watchCtx.Done():
+				}
+				return !failure.final
+			},
+		}
+		go func() {
+			target := watchTarget{namespace: namespace, labelSelector: selector}
+			watchDeployments(watchCtx, clientset, target, nil, watchOpts, func(eventType watch.EventType, old, current *appsv1.Deployment) {
+				event := DeploymentEvent{
+					Type:            string(eventType),
+					Namespace:       current.Namespace,
+					Name:            current.Name,
+					ResourceVersion: current.ResourceVersion,
+					Deployment:      newDeploymentStatus(current),
+				}
+				if eventType == watch.Modified && old != nil {
+					changes := diff.Deployments(old, current, opts)
+					if changes.Empty() {
+						return
+					}
+					event.Changes, event.Patch = changes.Changes, changes.Patch
+				}
+				select {
+				case events <- event:
+				case <-watchCtx.Done():
+				}
+			})
+		}()
+
+		fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+		heartbeat := time.NewTicker(watchHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			if err := w.Flush(); err != nil {
+				namespaceLogger.Debug("Deployment watch stream closed", map[string]interface{}{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			select {
+			case event := <-events:
+				data, _ := json.Marshal(event)
+				fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", event.Type, event.ResourceVersion, data)
+			case failure := <-failures:
+				apiErr := newAPIError(failure.err, fasthttp.StatusInternalServerError)
+				// Whether the stream retries, whatever the status code
+				apiErr.Retryable = !failure.final
+				apiErr.sanitize(reqCtx)
+				data, _ := json.Marshal(apiErr)
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+				if failure.final {
+					namespaceLogger.Warn("Deployment watch stream ended", map[string]interface{}{
+						"error": failure.err.Error(),
+					})
+					w.Flush()
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+		}
+	})
+}
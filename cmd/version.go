@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/version"
+)
+
+var versionOutput string
+
+// versionCmd prints the build information
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and build information",
+	Args:  cobra.NoArgs,
+	// Printing the version needs no logging or tracing setup
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Get()
+		switch versionOutput {
+		case "text":
+			fmt.Fprintln(cmd.OutOrStdout(), info.String())
+		case "json":
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		default:
+			return fmt.Errorf("unknown output format %q (expected text or json)", versionOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "text", "Output format: text or json")
+}
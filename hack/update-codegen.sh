@@ -0,0 +1,51 @@
+#!/bin/bash
+
+# Regenerate the deepcopy functions, the typed clientset, listers and
+# informers of pkg/apis, and the CRD manifests in charts/app/crds. The
+# generators are pinned in hack/tools/go.mod.
+set -euo pipefail
+
+cd "$(dirname "$0")/.."
+
+MODULE=github.com/yourusername/k8s-controller-tutorial
+APIS=(./pkg/apis/monitoring/v1alpha1)
+OUTPUT=pkg/generated
+HEADER=hack/boilerplate.go.txt
+
+tool() {
+	go tool -modfile=hack/tools/go.mod "$@"
+}
+
+# Generated files of API versions that no longer exist must not linger
+rm -rf "${OUTPUT}"
+find pkg -name zz_generated.deepcopy.go -delete
+
+echo "Generating deepcopy functions"
+# pkg/alerts holds the rule types embedded in DeploymentMonitorSpec
+tool deepcopy-gen --go-header-file "${HEADER}" --output-file zz_generated.deepcopy.go \
+	"${APIS[@]}" ./pkg/alerts
+
+echo "Generating clientset"
+tool client-gen --go-header-file "${HEADER}" \
+	--clientset-name versioned \
+	--input-base "" \
+	--input "${APIS[@]/#./${MODULE}}" \
+	--output-pkg "${MODULE}/${OUTPUT}/clientset" \
+	--output-dir "${OUTPUT}/clientset"
+
+echo "Generating listers"
+tool lister-gen --go-header-file "${HEADER}" \
+	--output-pkg "${MODULE}/${OUTPUT}/listers" \
+	--output-dir "${OUTPUT}/listers" \
+	"${APIS[@]}"
+
+echo "Generating informers"
+tool informer-gen --go-header-file "${HEADER}" \
+	--versioned-clientset-package "${MODULE}/${OUTPUT}/clientset/versioned" \
+	--listers-package "${MODULE}/${OUTPUT}/listers" \
+	--output-pkg "${MODULE}/${OUTPUT}/informers" \
+	--output-dir "${OUTPUT}/informers" \
+	"${APIS[@]}"
+
+echo "Generating CRDs"
+tool controller-gen crd paths=./pkg/apis/... output:crd:artifacts:config=charts/app/crds
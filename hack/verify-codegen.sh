@@ -0,0 +1,32 @@
+#!/bin/bash
+
+# Fail when the generated code or CRDs differ from what hack/update-codegen.sh
+# produces, e.g. after changing pkg/apis without running make generate. The
+# generators run in a temporary copy of the repository, so the working tree
+# is left as it is.
+set -euo pipefail
+
+cd "$(dirname "$0")/.."
+
+GENERATED=(pkg/generated pkg/apis pkg/alerts charts/app/crds)
+
+_tmp="$(mktemp -d -t verify-codegen.XXXXXX)"
+cleanup() {
+	rm -rf "${_tmp}"
+}
+trap cleanup EXIT INT TERM
+
+# Copy the working tree, uncommitted changes included, without .git
+tar -c --exclude=./.git . | tar -x -C "${_tmp}"
+(cd "${_tmp}" && hack/update-codegen.sh >/dev/null)
+
+echo "Diffing ${GENERATED[*]} against freshly generated code"
+ret=0
+for path in "${GENERATED[@]}"; do
+	diff -Naupr "${path}" "${_tmp}/${path}" || ret=$?
+done
+if [ "${ret}" -ne 0 ]; then
+	echo "Generated code is out of date; run make generate and commit the result" >&2
+	exit 1
+fi
+echo "Generated code is up to date"
@@ -0,0 +1,269 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Resources a condition reads
+const (
+	needDeployments = 1 << iota
+	needPods
+	needEvents
+)
+
+// resource names the resource c reads
+func resource(c condition) string {
+	switch c.needs() {
+	case needPods:
+		return "pods"
+	case needEvents:
+		return "events"
+	}
+	return "deployments"
+}
+
+// snapshot is the state of the cluster at one evaluation
+type snapshot struct {
+	time        time.Time
+	deployments []appsv1.Deployment
+	pods        []corev1.Pod
+	events      []corev1.Event
+}
+
+// match is an object a condition holds for
+type match struct {
+	namespace string
+	kind      string
+	name      string
+	message   string
+}
+
+// condition finds the objects a rule fires for. Conditions may keep history
+// between evaluations.
+type condition interface {
+	needs() int
+	matches(s *snapshot) []match
+}
+
+// newCondition returns the condition set in r; r must be valid
+func newCondition(r Rule) condition {
+	switch {
+	case r.ReadyBelowDesired != nil:
+		return &readyBelowDesired{margin: r.ReadyBelowDesired.Margin}
+	case r.RestartIncrease != nil:
+		return &restartIncrease{
+			increase: r.RestartIncrease.Increase,
+			window:   r.RestartIncrease.Window.Duration,
+			history:  make(map[types.UID][]restartSample),
+		}
+	case r.WarningEvent != nil:
+		c := &warningEvent{window: r.WarningEvent.Window.Duration, reasons: make(map[string]bool)}
+		if c.window == 0 {
+			c.window = defaultEventWindow
+		}
+		for _, reason := range r.WarningEvent.Reasons {
+			c.reasons[reason] = true
+		}
+		return c
+	default:
+		c := &imageTag{tags: make(map[string]bool)}
+		tags := r.ImageTag.Tags
+		if len(tags) == 0 {
+			tags = []string{"latest"}
+		}
+		for _, tag := range tags {
+			c.tags[tag] = true
+		}
+		return c
+	}
+}
+
+type readyBelowDesired struct {
+	margin int32
+}
+
+func (c *readyBelowDesired) needs() int { return needDeployments }
+
+func (c *readyBelowDesired) matches(s *snapshot) []match {
+	var matches []match
+	for i := range s.deployments {
+		d := &s.deployments[i]
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		if desired-d.Status.ReadyReplicas > c.margin {
+			matches = append(matches, match{
+				namespace: d.Namespace,
+				kind:      "Deployment",
+				name:      d.Name,
+				message:   fmt.Sprintf("%d of %d replicas ready", d.Status.ReadyReplicas, desired),
+			})
+		}
+	}
+	return matches
+}
+
+type restartIncrease struct {
+	increase int32
+	window   time.Duration
+	// history holds the restart counts of each pod seen within the window
+	history map[types.UID][]restartSample
+}
+
+type restartSample struct {
+	time     time.Time
+	restarts int32
+}
+
+func (c *restartIncrease) needs() int { return needPods }
+
+func (c *restartIncrease) matches(s *snapshot) []match {
+	var matches []match
+	seen := make(map[types.UID]bool, len(s.pods))
+	for i := range s.pods {
+		pod := &s.pods[i]
+		seen[pod.UID] = true
+
+		var restarts int32
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+
+		// Keep the samples within the window; the oldest is the baseline
+		samples := c.history[pod.UID]
+		for len(samples) > 0 && s.time.Sub(samples[0].time) > c.window {
+			samples = samples[1:]
+		}
+		samples = append(samples, restartSample{time: s.time, restarts: restarts})
+		c.history[pod.UID] = samples
+
+		if increase := restarts - samples[0].restarts; increase >= c.increase {
+			matches = append(matches, match{
+				namespace: pod.Namespace,
+				kind:      "Pod",
+				name:      pod.Name,
+				message:   fmt.Sprintf("restarted %d times in the last %s", increase, c.window),
+			})
+		}
+	}
+
+	for uid := range c.history {
+		if !seen[uid] {
+			delete(c.history, uid)
+		}
+	}
+	return matches
+}
+
+type warningEvent struct {
+	reasons map[string]bool
+	window  time.Duration
+}
+
+func (c *warningEvent) needs() int { return needEvents }
+
+func (c *warningEvent) matches(s *snapshot) []match {
+	// One match per object, with its latest event
+	var keys []string
+	latest := make(map[string]match)
+	latestAt := make(map[string]time.Time)
+	for i := range s.events {
+		e := &s.events[i]
+		if e.Type != corev1.EventTypeWarning || (len(c.reasons) > 0 && !c.reasons[e.Reason]) {
+			continue
+		}
+		at := eventTime(e)
+		if s.time.Sub(at) > c.window {
+			continue
+		}
+
+		object := e.InvolvedObject
+		key := e.Namespace + "/" + object.Kind + "/" + object.Name
+		previous, seen := latestAt[key]
+		if seen && !at.After(previous) {
+			continue
+		}
+		if !seen {
+			keys = append(keys, key)
+		}
+		latestAt[key] = at
+		latest[key] = match{
+			namespace: e.Namespace,
+			kind:      object.Kind,
+			name:      object.Name,
+			message:   fmt.Sprintf("%s: %s", e.Reason, strings.TrimSpace(e.Message)),
+		}
+	}
+
+	matches := make([]match, 0, len(keys))
+	for _, key := range keys {
+		matches = append(matches, latest[key])
+	}
+	return matches
+}
+
+// eventTime returns when an event was last seen
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+type imageTag struct {
+	tags map[string]bool
+}
+
+func (c *imageTag) needs() int { return needDeployments }
+
+func (c *imageTag) matches(s *snapshot) []match {
+	var matches []match
+	for i := range s.deployments {
+		d := &s.deployments[i]
+		spec := d.Spec.Template.Spec
+		var offending []string
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for _, container := range containers {
+				if c.tags[tagOf(container.Image)] {
+					offending = append(offending, fmt.Sprintf("%s (%s)", container.Name, container.Image))
+				}
+			}
+		}
+		if len(offending) > 0 {
+			matches = append(matches, match{
+				namespace: d.Namespace,
+				kind:      "Deployment",
+				name:      d.Name,
+				message:   "containers use a mutable image tag: " + strings.Join(offending, ", "),
+			})
+		}
+	}
+	return matches
+}
+
+// tagOf returns the tag of an image reference; an image without tag or
+// digest is latest, one pinned by digest has no tag
+func tagOf(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	// The last colon after the last slash separates the tag; a colon before
+	// it belongs to the registry port
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return "latest"
+}
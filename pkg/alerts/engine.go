@@ -0,0 +1,402 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+)
+
+// States of an alert
+const (
+	// StatePending: the condition holds, but not yet for the rule's for
+	StatePending = "pending"
+	// StateFiring: the condition has held for at least for
+	StateFiring = "firing"
+	// StateResolved: the condition stopped holding after the alert fired
+	StateResolved = "resolved"
+)
+
+// DefaultRetention is how long resolved alerts stay listed
+const DefaultRetention = 15 * time.Minute
+
+// Alert is the state of one rule for one object
+type Alert struct {
+	Rule        string            `json:"rule"`
+	State       string            `json:"state"`
+	Severity    string            `json:"severity"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Namespace   string            `json:"namespace"`
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	// Resource is what the alert was derived from: deployments, pods or
+	// events. The message of an event alert is the event's.
+	Resource string `json:"resource"`
+	// Message describes the object's state at the last evaluation
+	Message string `json:"message"`
+	// ActiveAt is when the condition started to hold
+	ActiveAt   time.Time  `json:"active_at"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Engine evaluates rules against the deployments, pods and events of a
+// namespace and tracks the state of their alerts
+type Engine struct {
+	client    kubernetes.Interface
+	namespace string
+	pageSize  int64
+	interval  atomic.Int64
+	// now returns the time of an evaluation
+	now func() time.Time
+
+	mu     sync.Mutex
+	rules  []*rule
+	alerts map[string]*Alert
+	// transitions are the alerts that fired or resolved while locked; they
+	// are passed to Changed after unlocking
+	transitions []Alert
+
+	// Retention is how long resolved alerts stay listed
+	Retention time.Duration
+	// Selector, if set, limits the rules to the deployments it selects,
+	// their pods and the events about either
+	Selector labels.Selector
+	// Changed, if set, is called when an alert fires or resolves
+	Changed func(alert Alert)
+	// Evaluated, if set, is called after every evaluation with the current
+	// alerts, or the error that stopped it
+	Evaluated func(alerts []Alert, err error)
+}
+
+// rule is a rule with the history of its condition
+type rule struct {
+	Rule
+	condition condition
+}
+
+// NewEngine creates an engine without rules for namespace; an empty
+// namespace means all namespaces. Lists are fetched in pages of pageSize.
+func NewEngine(client kubernetes.Interface, namespace string, pageSize int64, interval time.Duration) *Engine {
+	e := &Engine{
+		client:    client,
+		namespace: namespace,
+		pageSize:  pageSize,
+		alerts:    make(map[string]*Alert),
+		now:       time.Now,
+		Retention: DefaultRetention,
+	}
+	e.SetInterval(interval)
+	return e
+}
+
+// SetInterval changes how often Run evaluates the rules
+func (e *Engine) SetInterval(interval time.Duration) {
+	e.interval.Store(int64(interval))
+}
+
+// SetRules replaces the rules. Unchanged rules keep their history and
+// alerts; the firing alerts of removed or changed rules are resolved.
+func (e *Engine) SetRules(rules []Rule) error {
+	if err := Validate(rules); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.notify()
+
+	previous := make(map[string]*rule, len(e.rules))
+	for _, r := range e.rules {
+		previous[r.Name] = r
+	}
+
+	compiled := make([]*rule, 0, len(rules))
+	kept := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if old, ok := previous[r.Name]; ok && reflect.DeepEqual(old.Rule, r) {
+			compiled = append(compiled, old)
+			kept[r.Name] = true
+			continue
+		}
+		compiled = append(compiled, &rule{Rule: r, condition: newCondition(r)})
+	}
+	e.rules = compiled
+
+	now := e.now()
+	for key, alert := range e.alerts {
+		if !kept[alert.Rule] {
+			e.resolve(key, alert, now)
+		}
+	}
+	return nil
+}
+
+// Run evaluates the rules every interval until ctx is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	for {
+		err := e.Evaluate(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if e.Evaluated != nil {
+			e.Evaluated(e.Alerts(), err)
+		}
+
+		timer := time.NewTimer(time.Duration(e.interval.Load()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Evaluate lists the resources the rules need and updates the alerts. When a
+// list fails, the alerts are left as they are.
+func (e *Engine) Evaluate(ctx context.Context) error {
+	e.mu.Lock()
+	rules := append([]*rule(nil), e.rules...)
+	e.mu.Unlock()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	needs := 0
+	for _, r := range rules {
+		needs |= r.condition.needs()
+	}
+	s, err := e.snapshot(ctx, needs)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.notify()
+	for _, r := range rules {
+		e.update(r, r.condition.matches(s), s.time)
+	}
+	for key, alert := range e.alerts {
+		if alert.State == StateResolved && s.time.Sub(*alert.ResolvedAt) > e.Retention {
+			delete(e.alerts, key)
+		}
+	}
+	return nil
+}
+
+// update moves the alerts of r through pending, firing and resolved
+func (e *Engine) update(r *rule, matches []match, now time.Time) {
+	active := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		key := alertKey(r.Name, m)
+		active[key] = true
+
+		alert, ok := e.alerts[key]
+		if !ok || alert.State == StateResolved {
+			alert = &Alert{
+				Rule:        r.Name,
+				State:       StatePending,
+				Severity:    r.severity(),
+				Labels:      r.Labels,
+				Description: r.Description,
+				Namespace:   m.namespace,
+				Kind:        m.kind,
+				Name:        m.name,
+				Resource:    resource(r.condition),
+				ActiveAt:    now,
+			}
+			e.alerts[key] = alert
+		}
+		alert.Message = m.message
+
+		if alert.State == StatePending && now.Sub(alert.ActiveAt) >= r.For.Duration {
+			alert.State = StateFiring
+			alert.FiredAt = &now
+			e.changed(alert)
+		}
+	}
+
+	for key, alert := range e.alerts {
+		if alert.Rule == r.Name && !active[key] {
+			e.resolve(key, alert, now)
+		}
+	}
+}
+
+// resolve ends an alert: a pending alert is dropped, a firing one resolved
+func (e *Engine) resolve(key string, alert *Alert, now time.Time) {
+	switch alert.State {
+	case StatePending:
+		delete(e.alerts, key)
+	case StateFiring:
+		alert.State = StateResolved
+		alert.ResolvedAt = &now
+		e.changed(alert)
+	}
+}
+
+func (e *Engine) changed(alert *Alert) {
+	e.transitions = append(e.transitions, *alert)
+}
+
+// notify unlocks the engine and passes the transitions to Changed
+func (e *Engine) notify() {
+	transitions := e.transitions
+	e.transitions = nil
+	e.mu.Unlock()
+
+	if e.Changed != nil {
+		for _, alert := range transitions {
+			e.Changed(alert)
+		}
+	}
+}
+
+// Alerts returns the pending, firing and recently resolved alerts: firing
+// first, then by severity, rule and object
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	alerts := make([]Alert, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		alerts = append(alerts, *alert)
+	}
+	e.mu.Unlock()
+
+	stateOrder := map[string]int{StateFiring: 0, StatePending: 1, StateResolved: 2}
+	severityOrder := map[string]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		switch {
+		case a.State != b.State:
+			return stateOrder[a.State] < stateOrder[b.State]
+		case a.Severity != b.Severity:
+			return severityOrder[a.Severity] < severityOrder[b.Severity]
+		case a.Rule != b.Rule:
+			return a.Rule < b.Rule
+		}
+		return a.Namespace+"/"+a.Kind+"/"+a.Name < b.Namespace+"/"+b.Kind+"/"+b.Name
+	})
+	return alerts
+}
+
+// snapshot lists the resources in needs
+func (e *Engine) snapshot(ctx context.Context, needs int) (*snapshot, error) {
+	s := &snapshot{}
+	var selector string
+	if e.Selector != nil {
+		selector = e.Selector.String()
+		// Pods and events are scoped through the selected deployments
+		if needs&(needPods|needEvents) != 0 {
+			needs |= needDeployments | needPods
+		}
+	}
+	if needs&needDeployments != 0 {
+		err := e.list(ctx, selector, func(opts metav1.ListOptions) (runtime.Object, error) {
+			return e.client.AppsV1().Deployments(e.namespace).List(ctx, opts)
+		}, func(obj runtime.Object) error {
+			item, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return fmt.Errorf("unexpected object %T", obj)
+			}
+			s.deployments = append(s.deployments, *item)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %v", err)
+		}
+	}
+	if needs&needPods != 0 {
+		err := e.list(ctx, "", func(opts metav1.ListOptions) (runtime.Object, error) {
+			return e.client.CoreV1().Pods(e.namespace).List(ctx, opts)
+		}, func(obj runtime.Object) error {
+			item, ok := obj.(*corev1.Pod)
+			if !ok {
+				return fmt.Errorf("unexpected object %T", obj)
+			}
+			s.pods = append(s.pods, *item)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %v", err)
+		}
+	}
+	if needs&needEvents != 0 {
+		err := e.list(ctx, "", func(opts metav1.ListOptions) (runtime.Object, error) {
+			return e.client.CoreV1().Events(e.namespace).List(ctx, opts)
+		}, func(obj runtime.Object) error {
+			item, ok := obj.(*corev1.Event)
+			if !ok {
+				return fmt.Errorf("unexpected object %T", obj)
+			}
+			s.events = append(s.events, *item)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %v", err)
+		}
+	}
+	if e.Selector != nil {
+		s.scope()
+	}
+	s.time = e.now()
+	return s, nil
+}
+
+// scope keeps the pods of the listed deployments and the events about the
+// listed deployments and those pods
+func (s *snapshot) scope() {
+	selectors := make([]labels.Selector, 0, len(s.deployments))
+	objects := make(map[string]bool, len(s.deployments))
+	for _, d := range s.deployments {
+		objects["Deployment/"+d.Namespace+"/"+d.Name] = true
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		selectors = append(selectors, selector)
+	}
+
+	pods := s.pods[:0]
+	for _, p := range s.pods {
+		for _, selector := range selectors {
+			if selector.Matches(labels.Set(p.Labels)) {
+				pods = append(pods, p)
+				objects["Pod/"+p.Namespace+"/"+p.Name] = true
+				break
+			}
+		}
+	}
+	s.pods = pods
+
+	events := s.events[:0]
+	for _, ev := range s.events {
+		if objects[ev.InvolvedObject.Kind+"/"+ev.InvolvedObject.Namespace+"/"+ev.InvolvedObject.Name] {
+			events = append(events, ev)
+		}
+	}
+	s.events = events
+}
+
+// list pages through a list of the objects matching selector and calls add
+// for every item
+func (e *Engine) list(ctx context.Context, selector string, page func(opts metav1.ListOptions) (runtime.Object, error), add func(obj runtime.Object) error) error {
+	p := pager.New(pager.SimplePageFunc(page))
+	p.PageSize = e.pageSize
+	return p.EachListItem(ctx, metav1.ListOptions{LabelSelector: selector}, add)
+}
+
+func alertKey(rule string, m match) string {
+	return rule + "/" + m.namespace + "/" + m.kind + "/" + m.name
+}
@@ -0,0 +1,302 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testEngine is an engine over a fake clientset whose evaluations happen at
+// the time it is set to
+type testEngine struct {
+	*Engine
+	t       *testing.T
+	client  *fake.Clientset
+	now     time.Time
+	changed []Alert
+}
+
+func newTestEngine(t *testing.T, rules ...Rule) *testEngine {
+	t.Helper()
+	te := &testEngine{t: t, client: fake.NewSimpleClientset(), now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	te.Engine = NewEngine(te.client, "shop", 100, time.Minute)
+	te.Engine.now = func() time.Time { return te.now }
+	te.Changed = func(alert Alert) { te.changed = append(te.changed, alert) }
+	if err := te.SetRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	return te
+}
+
+// setReady creates or updates the deployment name with 3 desired replicas,
+// of which ready are ready
+func (te *testEngine) setReady(name string, ready int32) {
+	te.t.Helper()
+	replicas := int32(3)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+	}
+	deployments := te.client.AppsV1().Deployments("shop")
+	if _, err := deployments.Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+		_, err = deployments.Create(context.Background(), d, metav1.CreateOptions{})
+		if err != nil {
+			te.t.Fatal(err)
+		}
+		return
+	}
+	if _, err := deployments.Update(context.Background(), d, metav1.UpdateOptions{}); err != nil {
+		te.t.Fatal(err)
+	}
+}
+
+// evaluate evaluates the rules after advancing the clock by d and returns
+// the alerts
+func (te *testEngine) evaluate(d time.Duration) []Alert {
+	te.t.Helper()
+	te.now = te.now.Add(d)
+	if err := te.Evaluate(context.Background()); err != nil {
+		te.t.Fatal(err)
+	}
+	return te.Alerts()
+}
+
+// takeChanged returns the states passed to Changed since the last call
+func (te *testEngine) takeChanged() []string {
+	var states []string
+	for _, alert := range te.changed {
+		states = append(states, alert.State)
+	}
+	te.changed = nil
+	return states
+}
+
+func notReady(name string, wait time.Duration) Rule {
+	return Rule{
+		Name:              name,
+		For:               metav1.Duration{Duration: wait},
+		Severity:          SeverityCritical,
+		ReadyBelowDesired: &ReadyBelowDesired{},
+	}
+}
+
+// expect fails unless alerts hold one alert in state, or none if state is
+// empty
+func expect(t *testing.T, alerts []Alert, state string) {
+	t.Helper()
+	switch {
+	case state == "" && len(alerts) == 0:
+	case state != "" && len(alerts) == 1 && alerts[0].State == state:
+	default:
+		t.Fatalf("alerts = %+v, want one %q", alerts, state)
+	}
+}
+
+func equalStates(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAlertLifecycle(t *testing.T) {
+	te := newTestEngine(t, notReady("NotReady", 5*time.Minute))
+	te.setReady("web", 1)
+	start := te.now
+
+	alerts := te.evaluate(0)
+	expect(t, alerts, StatePending)
+	if a := alerts[0]; !a.ActiveAt.Equal(start) || a.Message != "1 of 3 replicas ready" || a.Kind != "Deployment" || a.Name != "web" {
+		t.Errorf("pending alert = %+v", a)
+	}
+	expect(t, te.evaluate(4*time.Minute+59*time.Second), StatePending)
+	if changed := te.takeChanged(); changed != nil {
+		t.Errorf("pending alert reported as %v", changed)
+	}
+
+	// The alert fires once the condition held for the rule's for
+	te.setReady("web", 2)
+	alerts = te.evaluate(time.Second)
+	expect(t, alerts, StateFiring)
+	if a := alerts[0]; !a.ActiveAt.Equal(start) || !a.FiredAt.Equal(start.Add(5*time.Minute)) || a.Message != "2 of 3 replicas ready" {
+		t.Errorf("firing alert = %+v", a)
+	}
+	expect(t, te.evaluate(time.Minute), StateFiring)
+	if changed := te.takeChanged(); !equalStates(changed, StateFiring) {
+		t.Errorf("changes = %v, want one firing", changed)
+	}
+
+	te.setReady("web", 3)
+	alerts = te.evaluate(time.Minute)
+	expect(t, alerts, StateResolved)
+	resolved := te.now
+	if a := alerts[0]; !a.ResolvedAt.Equal(resolved) || a.FiredAt == nil {
+		t.Errorf("resolved alert = %+v", a)
+	}
+	if changed := te.takeChanged(); !equalStates(changed, StateResolved) {
+		t.Errorf("changes = %v, want one resolved", changed)
+	}
+
+	// Resolved alerts are listed for the retention
+	expect(t, te.evaluate(DefaultRetention), StateResolved)
+	expect(t, te.evaluate(time.Second), "")
+
+	// The condition holding again starts a new pending alert
+	te.setReady("web", 0)
+	alerts = te.evaluate(time.Minute)
+	expect(t, alerts, StatePending)
+	if !alerts[0].ActiveAt.Equal(te.now) {
+		t.Errorf("new alert active at %s, want %s", alerts[0].ActiveAt, te.now)
+	}
+}
+
+func TestResolvedAlertHoldingAgain(t *testing.T) {
+	te := newTestEngine(t, notReady("NotReady", 0))
+	te.Retention = time.Hour
+	te.setReady("web", 1)
+
+	expect(t, te.evaluate(0), StateFiring)
+	te.setReady("web", 3)
+	expect(t, te.evaluate(time.Minute), StateResolved)
+
+	// Within the retention the resolved alert is replaced, not reused
+	te.setReady("web", 1)
+	alerts := te.evaluate(time.Minute)
+	expect(t, alerts, StateFiring)
+	if a := alerts[0]; a.ResolvedAt != nil || !a.ActiveAt.Equal(te.now) {
+		t.Errorf("alert firing again = %+v", a)
+	}
+	if changed := te.takeChanged(); !equalStates(changed, StateFiring, StateResolved, StateFiring) {
+		t.Errorf("changes = %v", changed)
+	}
+}
+
+func TestPendingAlertDropped(t *testing.T) {
+	te := newTestEngine(t, notReady("NotReady", 5*time.Minute))
+	te.setReady("web", 1)
+	expect(t, te.evaluate(0), StatePending)
+
+	te.setReady("web", 3)
+	expect(t, te.evaluate(time.Minute), "")
+	if changed := te.takeChanged(); changed != nil {
+		t.Errorf("dropped pending alert reported as %v", changed)
+	}
+
+	// The for starts over
+	te.setReady("web", 1)
+	expect(t, te.evaluate(time.Minute), StatePending)
+	expect(t, te.evaluate(4*time.Minute), StatePending)
+	expect(t, te.evaluate(time.Minute), StateFiring)
+}
+
+func TestSetRulesKeepsHistory(t *testing.T) {
+	te := newTestEngine(t, notReady("NotReady", 5*time.Minute), notReady("NotReadyNow", 0))
+	te.setReady("web", 1)
+	expect(t, te.evaluate(0)[1:], StatePending)
+	start := te.now
+
+	// Reloading the same rules keeps the pending alert and when it started
+	te.now = te.now.Add(time.Minute)
+	if err := te.SetRules([]Rule{notReady("NotReady", 5*time.Minute), notReady("NotReadyNow", 0)}); err != nil {
+		t.Fatal(err)
+	}
+	alerts := te.evaluate(4 * time.Minute)
+	if len(alerts) != 2 || alerts[0].State != StateFiring || alerts[1].State != StateFiring || !alerts[0].ActiveAt.Equal(start) {
+		t.Fatalf("alerts after reloading unchanged rules = %+v", alerts)
+	}
+	te.takeChanged()
+
+	// A changed rule starts over and a removed one resolves
+	if err := te.SetRules([]Rule{notReady("NotReady", 10*time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if changed := te.takeChanged(); !equalStates(changed, StateResolved, StateResolved) {
+		t.Errorf("changes after reload = %v, want two resolved", changed)
+	}
+	// The new pending alert replaces the resolved one of the same rule
+	alerts = te.evaluate(time.Minute)
+	if len(alerts) != 2 || alerts[0].State != StatePending || !alerts[0].ActiveAt.Equal(te.now) || alerts[1].Rule != "NotReadyNow" {
+		t.Fatalf("alerts after changing the rule = %+v", alerts)
+	}
+
+	// Invalid rules are rejected and the current ones kept
+	if err := te.SetRules([]Rule{{Name: "Broken"}}); err == nil {
+		t.Error("SetRules accepted a rule without condition")
+	}
+	if alerts := te.evaluate(10 * time.Minute); alerts[0].State != StateFiring || alerts[0].Rule != "NotReady" {
+		t.Errorf("alerts after an invalid reload = %+v", alerts)
+	}
+}
+
+func TestRestartIncrease(t *testing.T) {
+	c := newCondition(Rule{RestartIncrease: &RestartIncrease{Increase: 3, Window: metav1.Duration{Duration: 10 * time.Minute}}}).(*restartIncrease)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := func(uid string, restarts ...int32) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-" + uid, UID: types.UID(uid)}}
+		for _, n := range restarts {
+			p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{RestartCount: n})
+		}
+		return p
+	}
+
+	tests := []struct {
+		at   time.Duration
+		pods []corev1.Pod
+		want []string
+	}{
+		// Restarts before the first sample do not count
+		{at: 0, pods: []corev1.Pod{pod("a", 5), pod("b", 0)}},
+		{at: 5 * time.Minute, pods: []corev1.Pod{pod("a", 6), pod("b", 1, 1)}},
+		// Restarts of all containers add up
+		{at: 9 * time.Minute, pods: []corev1.Pod{pod("a", 7), pod("b", 2, 1)}, want: []string{"web-b"}},
+		{at: 10 * time.Minute, pods: []corev1.Pod{pod("a", 8), pod("b", 2, 1)}, want: []string{"web-a", "web-b"}},
+		// The samples at 0 and 5m left the window: the baselines are 7 and 3
+		{at: 16 * time.Minute, pods: []corev1.Pod{pod("a", 9), pod("b", 3, 2)}},
+		// A pod that disappears loses its history
+		{at: 17 * time.Minute, pods: []corev1.Pod{pod("a", 10)}, want: []string{"web-a"}},
+		{at: 18 * time.Minute, pods: []corev1.Pod{pod("a", 10), pod("b", 9, 9)}, want: []string{"web-a"}},
+	}
+	for _, tt := range tests {
+		matches := c.matches(&snapshot{time: start.Add(tt.at), pods: tt.pods})
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.name)
+		}
+		if !equalStates(got, tt.want...) {
+			t.Errorf("at %s: matches = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+	if len(c.history) != 2 || len(c.history["a"]) != 5 {
+		t.Errorf("history = %v", c.history)
+	}
+}
+
+func TestTagOf(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                                         "latest",
+		"nginx:1.27":                                    "1.27",
+		"library/nginx:latest":                          "latest",
+		"registry.example.com:5000/web":                 "latest",
+		"registry.example.com:5000/team/web:v2":         "v2",
+		"localhost:5000/web:latest":                     "latest",
+		"nginx@sha256:0123abcd":                         "",
+		"nginx:1.27@sha256:0123abcd":                    "",
+		"registry.example.com:5000/web@sha256:0123abcd": "",
+	} {
+		if got := tagOf(image); got != want {
+			t.Errorf("tagOf(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
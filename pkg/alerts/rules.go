@@ -0,0 +1,192 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Severities of a rule
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the valid severities from least to most severe
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// RuleFile is the format of a rules file:
+//
+//	rules:
+//	  - name: DeploymentNotReady
+//	    readyBelowDesired: {}
+//	    for: 5m
+//	    severity: critical
+//	    labels: {team: web}
+type RuleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule raises an alert for every object its condition holds for. Exactly
+// one condition must be set. Rules are also part of the DeploymentMonitor
+// API, hence the deepcopy and validation markers.
+//
+// +k8s:deepcopy-gen=true
+type Rule struct {
+	Name string `json:"name"`
+	// Description is shown with the alert
+	Description string `json:"description,omitempty"`
+	// For is how long the condition must hold before the alert fires; until
+	// then it is pending
+	For metav1.Duration `json:"for,omitempty"`
+	// +kubebuilder:validation:Enum=info;warning;critical
+	Severity string            `json:"severity,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+
+	ReadyBelowDesired *ReadyBelowDesired `json:"readyBelowDesired,omitempty"`
+	RestartIncrease   *RestartIncrease   `json:"restartIncrease,omitempty"`
+	WarningEvent      *WarningEvent      `json:"warningEvent,omitempty"`
+	ImageTag          *ImageTag          `json:"imageTag,omitempty"`
+}
+
+// ReadyBelowDesired holds for deployments with fewer ready replicas than
+// desired
+//
+// +k8s:deepcopy-gen=true
+type ReadyBelowDesired struct {
+	// Margin is how many replicas may be missing before the condition holds
+	Margin int32 `json:"margin,omitempty"`
+}
+
+// RestartIncrease holds for pods whose containers restarted at least
+// Increase times within Window
+//
+// +k8s:deepcopy-gen=true
+type RestartIncrease struct {
+	Increase int32           `json:"increase"`
+	Window   metav1.Duration `json:"window"`
+}
+
+// WarningEvent holds for objects with a Warning event seen within Window
+//
+// +k8s:deepcopy-gen=true
+type WarningEvent struct {
+	// Reasons limits the events, e.g. FailedScheduling or BackOff; empty
+	// means any reason
+	Reasons []string `json:"reasons,omitempty"`
+	// Window defaults to 10m
+	Window metav1.Duration `json:"window,omitempty"`
+}
+
+// ImageTag holds for deployments running a container image with one of Tags
+//
+// +k8s:deepcopy-gen=true
+type ImageTag struct {
+	// Tags defaults to latest; an image without tag or digest counts as latest
+	Tags []string `json:"tags,omitempty"`
+}
+
+// defaultEventWindow is the window of WarningEvent rules without one
+const defaultEventWindow = 10 * time.Minute
+
+// LoadRules reads and validates a rules file. Unknown keys are an error, so
+// typos are caught.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %v", err)
+	}
+	var file RuleFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules %s: %v", path, err)
+	}
+	if err := Validate(file.Rules); err != nil {
+		return nil, fmt.Errorf("alert rules %s: %v", path, err)
+	}
+	return file.Rules, nil
+}
+
+// Validate reports every problem of rules at once
+func Validate(rules []Rule) error {
+	var problems []string
+	names := make(map[string]bool)
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i)
+			problems = append(problems, name+": name is required")
+		} else if names[name] {
+			problems = append(problems, name+": duplicate name")
+		}
+		names[name] = true
+
+		if err := r.validate(); err != nil {
+			problems = append(problems, name+": "+err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid rules: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	if r.Severity != "" && !validSeverity(r.Severity) {
+		return fmt.Errorf("severity must be one of %s, got %q", strings.Join(Severities, ", "), r.Severity)
+	}
+	if r.For.Duration < 0 {
+		return fmt.Errorf("for must not be negative")
+	}
+
+	conditions := 0
+	if r.ReadyBelowDesired != nil {
+		conditions++
+		if r.ReadyBelowDesired.Margin < 0 {
+			return fmt.Errorf("readyBelowDesired.margin must not be negative")
+		}
+	}
+	if c := r.RestartIncrease; c != nil {
+		conditions++
+		if c.Increase <= 0 {
+			return fmt.Errorf("restartIncrease.increase must be positive")
+		}
+		if c.Window.Duration <= 0 {
+			return fmt.Errorf("restartIncrease.window must be positive")
+		}
+	}
+	if c := r.WarningEvent; c != nil {
+		conditions++
+		if c.Window.Duration < 0 {
+			return fmt.Errorf("warningEvent.window must not be negative")
+		}
+	}
+	if r.ImageTag != nil {
+		conditions++
+	}
+	if conditions != 1 {
+		return fmt.Errorf("exactly one of readyBelowDesired, restartIncrease, warningEvent or imageTag must be set")
+	}
+	return nil
+}
+
+// severity returns the severity of the rule, warning by default
+func (r Rule) severity() string {
+	if r.Severity == "" {
+		return SeverityWarning
+	}
+	return r.Severity
+}
+
+func validSeverity(severity string) bool {
+	for _, s := range Severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package alerts
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageTag) DeepCopyInto(out *ImageTag) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageTag.
+func (in *ImageTag) DeepCopy() *ImageTag {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageTag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadyBelowDesired) DeepCopyInto(out *ReadyBelowDesired) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadyBelowDesired.
+func (in *ReadyBelowDesired) DeepCopy() *ReadyBelowDesired {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadyBelowDesired)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartIncrease) DeepCopyInto(out *RestartIncrease) {
+	*out = *in
+	out.Window = in.Window
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartIncrease.
+func (in *RestartIncrease) DeepCopy() *RestartIncrease {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartIncrease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rule) DeepCopyInto(out *Rule) {
+	*out = *in
+	out.For = in.For
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReadyBelowDesired != nil {
+		in, out := &in.ReadyBelowDesired, &out.ReadyBelowDesired
+		*out = new(ReadyBelowDesired)
+		**out = **in
+	}
+	if in.RestartIncrease != nil {
+		in, out := &in.RestartIncrease, &out.RestartIncrease
+		*out = new(RestartIncrease)
+		**out = **in
+	}
+	if in.WarningEvent != nil {
+		in, out := &in.WarningEvent, &out.WarningEvent
+		*out = new(WarningEvent)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageTag != nil {
+		in, out := &in.ImageTag, &out.ImageTag
+		*out = new(ImageTag)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
+func (in *Rule) DeepCopy() *Rule {
+	if in == nil {
+		return nil
+	}
+	out := new(Rule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarningEvent) DeepCopyInto(out *WarningEvent) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Window = in.Window
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarningEvent.
+func (in *WarningEvent) DeepCopy() *WarningEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(WarningEvent)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,6 @@
+// Package v1alpha1 holds the monitoring.example.io/v1alpha1 API: the
+// DeploymentMonitor custom resource
+//
+// +k8s:deepcopy-gen=package
+// +groupName=monitoring.example.io
+package v1alpha1
@@ -0,0 +1,46 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group of the monitoring resources
+const GroupName = "monitoring.example.io"
+
+// SchemeGroupVersion is the group and version of this package
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// DeploymentMonitorResource is the resource of DeploymentMonitor objects
+var DeploymentMonitorResource = SchemeGroupVersion.WithResource("deploymentmonitors")
+
+// DeploymentMonitorKind is the kind of DeploymentMonitor objects
+const DeploymentMonitorKind = "DeploymentMonitor"
+
+var (
+	// SchemeBuilder registers the types of this package
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// AddToScheme adds the types of this package to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+// Kind returns the group-qualified kind
+func Kind(kind string) schema.GroupKind {
+	return SchemeGroupVersion.WithKind(kind).GroupKind()
+}
+
+// Resource returns the group-qualified resource; the generated listers use
+// it for not-found errors
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&DeploymentMonitor{},
+		&DeploymentMonitorList{},
+	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
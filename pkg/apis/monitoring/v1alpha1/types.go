@@ -0,0 +1,136 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// DeploymentMonitor selects deployments and declares their health thresholds,
+// alerting rules and notification targets. The controller evaluates it
+// periodically and reports the result in its status.
+//
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=dm
+// +kubebuilder:printcolumn:name="Matched",type=integer,JSONPath=`.status.matchedDeployments`
+// +kubebuilder:printcolumn:name="Healthy",type=integer,JSONPath=`.status.healthyDeployments`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Last Evaluation",type=date,JSONPath=`.status.lastEvaluationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type DeploymentMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentMonitorSpec `json:"spec"`
+	// +optional
+	Status DeploymentMonitorStatus `json:"status,omitempty"`
+}
+
+// DeploymentMonitorSpec is the desired monitoring of a set of deployments
+type DeploymentMonitorSpec struct {
+	// Namespaces holds the deployments to monitor; empty means the namespace
+	// of the monitor. Only monitors in namespaces the controller's
+	// configuration lists in controller.crossNamespaceMonitors may name other
+	// namespaces; other monitors are rejected with the reason InvalidSpec.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Selector selects deployments by label; empty selects all deployments
+	Selector metav1.LabelSelector `json:"selector,omitempty"`
+	// Thresholds decide whether a selected deployment is healthy
+	Thresholds HealthThresholds `json:"thresholds,omitempty"`
+	// Rules are alerting rules in the format of the rules file, scoped to the
+	// selected deployments, their pods and the events about them
+	Rules []alerts.Rule `json:"rules,omitempty"`
+	// Notifications names the sinks of the controller's notifications file
+	// that receive the alerts of the rules, whatever their routes
+	Notifications []string `json:"notifications,omitempty"`
+	// Interval is how often the monitor is evaluated; it defaults to the
+	// controller's alert interval
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// HealthThresholds decide whether a deployment is healthy. A deployment whose
+// rollout exceeded its progress deadline is never healthy.
+type HealthThresholds struct {
+	// MinReadyPercent is the share of desired replicas that must be ready
+	// (default 100)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MinReadyPercent *int32 `json:"minReadyPercent,omitempty"`
+	// MaxUnavailable is the number of unavailable replicas tolerated
+	// (default no limit)
+	// +kubebuilder:validation:Minimum=0
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+}
+
+// DeploymentMonitorStatus is the result of the last evaluation
+type DeploymentMonitorStatus struct {
+	// ObservedGeneration is the generation of the spec last evaluated
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastEvaluationTime is when the monitor was last evaluated
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	// MatchedDeployments is the number of selected deployments
+	// +optional
+	MatchedDeployments int32 `json:"matchedDeployments"`
+	// HealthyDeployments is the number of selected deployments within the
+	// thresholds
+	// +optional
+	HealthyDeployments int32 `json:"healthyDeployments"`
+	// Deployments lists the selected deployments, unhealthy first, up to
+	// MaxStatusEntries
+	// +optional
+	Deployments []MonitoredDeployment `json:"deployments"`
+	// FiringAlerts lists the firing alerts of the rules, up to
+	// MaxStatusEntries
+	// +optional
+	FiringAlerts []FiringAlert `json:"firingAlerts"`
+	// Conditions are Ready, whether the last evaluation succeeded, and
+	// Healthy, whether every selected deployment is healthy
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MaxStatusEntries limits the deployments and alerts listed in the status
+const MaxStatusEntries = 100
+
+// Condition types of a DeploymentMonitor
+const (
+	ConditionReady   = "Ready"
+	ConditionHealthy = "Healthy"
+)
+
+// MonitoredDeployment is the health of one selected deployment
+type MonitoredDeployment struct {
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	ReadyReplicas   int32  `json:"readyReplicas"`
+	DesiredReplicas int32  `json:"desiredReplicas"`
+	Healthy         bool   `json:"healthy"`
+	// Reason explains why the deployment is unhealthy
+	Reason string `json:"reason,omitempty"`
+}
+
+// FiringAlert is a firing alert of the monitor's rules
+type FiringAlert struct {
+	Rule      string      `json:"rule"`
+	Severity  string      `json:"severity"`
+	Namespace string      `json:"namespace"`
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Message   string      `json:"message"`
+	Since     metav1.Time `json:"since"`
+}
+
+// DeploymentMonitorList is a list of DeploymentMonitor objects
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type DeploymentMonitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeploymentMonitor `json:"items"`
+}
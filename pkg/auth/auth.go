@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ErrInvalidToken is returned when a bearer token is present but rejected
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// UnavailableError reports that a review could not be made because the API
+// server could not be reached. The request is neither allowed nor denied and
+// may be retried.
+type UnavailableError struct {
+	// Review names the review, such as "subject access review"
+	Review string
+	Err    error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Review, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// UserInfo describes an authenticated caller
+type UserInfo struct {
+	Name   string              `json:"name"`
+	UID    string              `json:"uid,omitempty"`
+	Groups []string            `json:"groups,omitempty"`
+	Extra  map[string][]string `json:"extra,omitempty"`
+
+	// Method is the authenticator that identified the caller
+	Method string `json:"method"`
+}
+
+// Authenticator identifies the caller of a request. It returns ok=false with
+// a nil error when the request carries no credentials it understands, so the
+// next authenticator can try.
+type Authenticator interface {
+	Authenticate(ctx *fasthttp.RequestCtx) (user *UserInfo, ok bool, err error)
+}
+
+// Union tries authenticators in order and returns the first user found. If
+// none succeeds, the first UnavailableError is returned, otherwise the first
+// error: a token that one authenticator rejects may be valid for another
+// that could not check it, so the caller should retry rather than discard
+// its credentials.
+type Union []Authenticator
+
+// Authenticate implements Authenticator
+func (u Union) Authenticate(ctx *fasthttp.RequestCtx) (*UserInfo, bool, error) {
+	var firstErr error
+	for _, authenticator := range u {
+		user, ok, err := authenticator.Authenticate(ctx)
+		if ok {
+			return user, true, nil
+		}
+		var unavailable *UnavailableError
+		if err != nil && (firstErr == nil || errors.As(err, &unavailable) && !errors.As(firstErr, &unavailable)) {
+			firstErr = err
+		}
+	}
+	return nil, false, firstErr
+}
+
+// BearerToken returns the token from an "Authorization: Bearer" header
+func BearerToken(ctx *fasthttp.RequestCtx) string {
+	header := strings.TrimSpace(string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)))
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestTokenFile returns a token file that knows the token "static"
+func newTestTokenFile(t *testing.T) *TokenFile {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	if err := os.WriteFile(path, []byte("static,alice,1,admins\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := NewTokenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+// newTestTokenReview returns a TokenReview authenticator whose API server
+// answers with review, or fails with err
+func newTestTokenReview(review *authenticationv1.TokenReview, err error) *TokenReview {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "tokenreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, review, err
+	})
+	return NewTokenReview(clientset, nil, time.Minute)
+}
+
+func TestUnionPrefersUnavailable(t *testing.T) {
+	unavailable := newTestTokenReview(nil, errors.New("connection refused"))
+	rejecting := newTestTokenReview(&authenticationv1.TokenReview{}, nil)
+	accepting := newTestTokenReview(&authenticationv1.TokenReview{
+		Status: authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "bob"}},
+	}, nil)
+
+	tests := []struct {
+		name        string
+		union       Union
+		token       string
+		user        string
+		unavailable bool
+		invalid     bool
+	}{
+		{name: "token file knows the token", union: Union{newTestTokenFile(t), unavailable}, token: "static", user: "alice"},
+		{name: "token file, then review unavailable", union: Union{newTestTokenFile(t), unavailable}, token: "sa-token", unavailable: true},
+		{name: "review unavailable, then token file", union: Union{unavailable, newTestTokenFile(t)}, token: "sa-token", unavailable: true},
+		{name: "token file, then review rejects", union: Union{newTestTokenFile(t), rejecting}, token: "sa-token", invalid: true},
+		{name: "token file, then review accepts", union: Union{newTestTokenFile(t), accepting}, token: "sa-token", user: "bob"},
+		{name: "no token", union: Union{newTestTokenFile(t), unavailable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init gives the context the server that its Done method needs
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(&fasthttp.Request{}, nil, nil)
+			if tt.token != "" {
+				ctx.Request.Header.Set(fasthttp.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			user, ok, err := tt.union.Authenticate(ctx)
+
+			if tt.user != "" {
+				if !ok || err != nil || user.Name != tt.user {
+					t.Fatalf("Authenticate() = %v, %v, %v, want %s", user, ok, err, tt.user)
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("Authenticate() authenticated %s", user.Name)
+			}
+			var unavailableErr *UnavailableError
+			if errors.As(err, &unavailableErr) != tt.unavailable {
+				t.Errorf("error %v, want unavailable %v", err, tt.unavailable)
+			}
+			if errors.Is(err, ErrInvalidToken) != tt.invalid {
+				t.Errorf("error %v, want invalid token %v", err, tt.invalid)
+			}
+		})
+	}
+}
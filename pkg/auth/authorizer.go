@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Authorization modes
+const (
+	ModeAlwaysAllow         = "AlwaysAllow"
+	ModeSubjectAccessReview = "SubjectAccessReview"
+)
+
+// maxCachedDecisions bounds the SubjectAccessReview cache
+const maxCachedDecisions = 4096
+
+// Attributes describe the access a request needs, in the same terms as
+// "kubectl auth can-i <verb> <resource>.<group> -n <namespace>"
+type Attributes struct {
+	Verb      string
+	Group     string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// String formats the attributes for logs and error messages
+func (a Attributes) String() string {
+	resource := a.Resource
+	if a.Group != "" {
+		resource += "." + a.Group
+	}
+	if a.Name != "" {
+		resource += "/" + a.Name
+	}
+	return fmt.Sprintf("%s %s in namespace %q", a.Verb, resource, a.Namespace)
+}
+
+// Decision is the result of an authorization check
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+// Authorizer decides whether a user may perform an action
+type Authorizer interface {
+	Authorize(ctx context.Context, user *UserInfo, attrs Attributes) (Decision, error)
+}
+
+// AlwaysAllow permits every request
+type AlwaysAllow struct{}
+
+// Authorize implements Authorizer
+func (AlwaysAllow) Authorize(context.Context, *UserInfo, Attributes) (Decision, error) {
+	return Decision{Allowed: true}, nil
+}
+
+// SubjectAccessReview asks the Kubernetes API server whether the caller is
+// allowed to perform an action, so callers only see what their own RBAC allows.
+// Decisions are cached briefly per user and attributes.
+type SubjectAccessReview struct {
+	clientset  kubernetes.Interface
+	allowedTTL time.Duration
+	deniedTTL  time.Duration
+	cache      *ttlCache[string, Decision]
+}
+
+// NewSubjectAccessReview creates a SubjectAccessReview authorizer
+func NewSubjectAccessReview(clientset kubernetes.Interface, allowedTTL, deniedTTL time.Duration) *SubjectAccessReview {
+	return &SubjectAccessReview{
+		clientset:  clientset,
+		allowedTTL: allowedTTL,
+		deniedTTL:  deniedTTL,
+		cache:      newTTLCache[string, Decision](maxCachedDecisions),
+	}
+}
+
+// Authorize implements Authorizer
+func (s *SubjectAccessReview) Authorize(ctx context.Context, user *UserInfo, attrs Attributes) (Decision, error) {
+	if user == nil {
+		return Decision{Reason: "request is not authenticated"}, nil
+	}
+
+	key := cacheKey(user, attrs)
+	if decision, ok := s.cache.get(key); ok {
+		return decision, nil
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Name,
+			UID:    user.UID,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: attrs.Namespace,
+				Verb:      attrs.Verb,
+				Group:     attrs.Group,
+				Resource:  attrs.Resource,
+				Name:      attrs.Name,
+			},
+		},
+	}
+	if len(user.Extra) > 0 {
+		review.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for k, v := range user.Extra {
+			review.Spec.Extra[k] = v
+		}
+	}
+
+	result, err := s.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return Decision{}, &UnavailableError{Review: "subject access review", Err: err}
+	}
+
+	decision := Decision{
+		Allowed: result.Status.Allowed && !result.Status.Denied,
+		Reason:  result.Status.Reason,
+	}
+	if decision.Allowed {
+		s.cache.set(key, decision, s.allowedTTL)
+	} else {
+		s.cache.set(key, decision, s.deniedTTL)
+	}
+	return decision, nil
+}
+
+// cacheKey identifies a user and attributes combination
+func cacheKey(user *UserInfo, attrs Attributes) string {
+	groups := append([]string(nil), user.Groups...)
+	sort.Strings(groups)
+
+	return strings.Join([]string{
+		user.Name, user.UID, strings.Join(groups, ","),
+		attrs.Verb, attrs.Group, attrs.Resource, attrs.Namespace, attrs.Name,
+	}, "\x00")
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache is a bounded cache whose entries expire after a fixed time
+type ttlCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]cacheEntry[V]
+	max     int
+}
+
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[K comparable, V any](max int) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		entries: make(map[K]cacheEntry[V]),
+		max:     max,
+	}
+}
+
+// get returns a cached value that has not expired
+func (c *ttlCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// set stores a value for ttl; a non-positive ttl disables caching
+func (c *ttlCache[K, V]) set(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.max {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full: drop arbitrary entries
+		for k := range c.entries {
+			if len(c.entries) < c.max {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expires: now.Add(ttl)}
+}
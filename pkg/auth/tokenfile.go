@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// TokenFile authenticates bearer tokens listed in a static CSV file using the
+// Kubernetes static token file format:
+//
+//	token,user,uid,"group1,group2"
+//
+// The uid and groups columns are optional.
+type TokenFile struct {
+	tokens map[string]*UserInfo
+}
+
+// NewTokenFile loads a static token file
+func NewTokenFile(path string) (*TokenFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	tokens := make(map[string]*UserInfo)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse token file line %d: %v", line, err)
+		}
+		if len(record) < 2 || record[0] == "" || record[1] == "" {
+			return nil, fmt.Errorf("token file line %d: expected at least token and user", line)
+		}
+		if _, exists := tokens[record[0]]; exists {
+			return nil, fmt.Errorf("token file line %d: duplicate token", line)
+		}
+
+		user := &UserInfo{Name: record[1], Method: "token-file"}
+		if len(record) > 2 {
+			user.UID = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			for _, group := range strings.Split(record[3], ",") {
+				user.Groups = append(user.Groups, strings.TrimSpace(group))
+			}
+		}
+		tokens[record[0]] = user
+	}
+
+	return &TokenFile{tokens: tokens}, nil
+}
+
+// Authenticate implements Authenticator
+func (t *TokenFile) Authenticate(ctx *fasthttp.RequestCtx) (*UserInfo, bool, error) {
+	token := BearerToken(ctx)
+	if token == "" {
+		return nil, false, nil
+	}
+
+	for candidate, user := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return user, true, nil
+		}
+	}
+	return nil, false, ErrInvalidToken
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxCachedReviews bounds the TokenReview cache
+const maxCachedReviews = 1024
+
+// TokenReview authenticates bearer tokens (ServiceAccount or OIDC) by asking
+// the Kubernetes API server with a TokenReview. Results are cached by token hash.
+type TokenReview struct {
+	clientset kubernetes.Interface
+	audiences []string
+	cacheTTL  time.Duration
+	timeout   time.Duration
+
+	// cache maps token hashes to users; a nil user records a rejected token
+	cache *ttlCache[[sha256.Size]byte, *UserInfo]
+}
+
+// NewTokenReview creates a TokenReview authenticator. Audiences may be empty
+// to accept the API server's default audience.
+func NewTokenReview(clientset kubernetes.Interface, audiences []string, cacheTTL time.Duration) *TokenReview {
+	return &TokenReview{
+		clientset: clientset,
+		audiences: audiences,
+		cacheTTL:  cacheTTL,
+		timeout:   10 * time.Second,
+		cache:     newTTLCache[[sha256.Size]byte, *UserInfo](maxCachedReviews),
+	}
+}
+
+// Authenticate implements Authenticator
+func (t *TokenReview) Authenticate(ctx *fasthttp.RequestCtx) (*UserInfo, bool, error) {
+	token := BearerToken(ctx)
+	if token == "" {
+		return nil, false, nil
+	}
+
+	key := sha256.Sum256([]byte(token))
+	if user, found := t.cache.get(key); found {
+		if user == nil {
+			return nil, false, ErrInvalidToken
+		}
+		return user, true, nil
+	}
+
+	reviewCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	review, err := t.clientset.AuthenticationV1().TokenReviews().Create(reviewCtx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: t.audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// Not cached: the API server may recover
+		return nil, false, &UnavailableError{Review: "token review", Err: err}
+	}
+
+	if !review.Status.Authenticated {
+		t.cache.set(key, nil, t.cacheTTL)
+		return nil, false, ErrInvalidToken
+	}
+
+	user := &UserInfo{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+		Method: "token-review",
+	}
+	if len(review.Status.User.Extra) > 0 {
+		user.Extra = make(map[string][]string, len(review.Status.User.Extra))
+		for k, v := range review.Status.User.Extra {
+			user.Extra[k] = v
+		}
+	}
+	t.cache.set(key, user, t.cacheTTL)
+	return user, true, nil
+}
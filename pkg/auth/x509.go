@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// ClientCert authenticates callers by a TLS client certificate that was
+// verified against the server's client CA. The certificate's common name is
+// the user name and its organizations are the groups, as in Kubernetes.
+type ClientCert struct{}
+
+// Authenticate implements Authenticator
+func (ClientCert) Authenticate(ctx *fasthttp.RequestCtx) (*UserInfo, bool, error) {
+	if !ctx.IsTLS() {
+		return nil, false, nil
+	}
+
+	state := ctx.TLSConnectionState()
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false, nil
+	}
+
+	certificate := state.VerifiedChains[0][0]
+	if certificate.Subject.CommonName == "" {
+		return nil, false, nil
+	}
+
+	return &UserInfo{
+		Name:   certificate.Subject.CommonName,
+		Groups: certificate.Subject.Organization,
+		Method: "x509",
+	}, true, nil
+}
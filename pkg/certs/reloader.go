@@ -0,0 +1,173 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay debounces bursts of file events, e.g. a Secret volume update
+// that replaces the certificate and key one after the other
+const reloadDelay = 500 * time.Millisecond
+
+// Reloader serves a TLS certificate and client CA bundle loaded from files and
+// reloads them when the files change, so rotated certificates (for example
+// from cert-manager) are picked up without a restart.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	certificate atomic.Pointer[tls.Certificate]
+	clientCAs   atomic.Pointer[x509.CertPool]
+}
+
+// NewReloader loads the certificate, key and optional client CA bundle. The
+// certificate files may be empty when the certificate is set with SetCertificate.
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("both a certificate and a private key file are required")
+	}
+
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetCertificate installs a certificate that is not backed by files
+func (r *Reloader) SetCertificate(certificate *tls.Certificate) {
+	r.certificate.Store(certificate)
+}
+
+// Reload reads all files again. On error the previous certificate and CA
+// bundle stay in use.
+func (r *Reloader) Reload() error {
+	var certificate *tls.Certificate
+	if r.certFile != "" {
+		loaded, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		certificate = &loaded
+	}
+
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.caFile)
+		}
+	}
+
+	if certificate != nil {
+		r.certificate.Store(certificate)
+	}
+	if clientCAs != nil {
+		r.clientCAs.Store(clientCAs)
+	}
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate := r.certificate.Load()
+	if certificate == nil {
+		return nil, fmt.Errorf("no TLS certificate loaded")
+	}
+	return certificate, nil
+}
+
+// TLSConfig returns a server TLS configuration that always uses the latest
+// certificate. With a client CA, client certificates are requested and
+// verified when presented.
+func (r *Reloader) TLSConfig(minVersion uint16) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: r.GetCertificate,
+	}
+	if r.caFile == "" {
+		return base
+	}
+
+	base.ClientAuth = tls.VerifyClientCertIfGiven
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		clientConfig := base.Clone()
+		clientConfig.ClientCAs = r.clientCAs.Load()
+		return clientConfig, nil
+	}
+	return config
+}
+
+// Watch reloads the files whenever they change until ctx is cancelled.
+// Parent directories are watched so atomic symlink swaps in Kubernetes Secret
+// volumes are noticed. onReload is called after every reload attempt.
+func (r *Reloader) Watch(ctx context.Context, onReload func(err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %v", err)
+	}
+
+	dirs := make(map[string]bool)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %v", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				timer = time.After(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onReload(fmt.Errorf("file watcher error: %v", err))
+			case <-timer:
+				timer = nil
+				onReload(r.Reload())
+			}
+		}
+	}()
+	return nil
+}
+
+// ParseTLSVersion converts a version name such as VersionTLS12 or 1.2
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "VersionTLS12", "1.2":
+		return tls.VersionTLS12, nil
+	case "VersionTLS13", "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q (expected VersionTLS12 or VersionTLS13)", version)
+}
@@ -0,0 +1,147 @@
+package compress
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// Supported content encodings, in order of preference when a client accepts
+// several with the same quality
+const (
+	Zstd   = "zstd"
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+var preference = []string{Zstd, Brotli, Gzip}
+
+// DefaultMinSize is the smallest body worth compressing; smaller bodies often
+// grow when compressed
+const DefaultMinSize = 860
+
+// compressibleTypes are the content types that are compressed
+var compressibleTypes = []string{"application/json", "text/"}
+
+// Middleware compresses response bodies with the encoding the client prefers
+// in Accept-Encoding. Bodies smaller than minSize, streamed bodies, bodies
+// that are already encoded and binary content types are left alone.
+//
+// Strong ETags get the encoding appended ("abc" becomes "abc-gzip"), because
+// the encoded body is a different representation; use ETagMatches to compare
+// them with If-None-Match.
+func Middleware(minSize int) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+
+			resp := &ctx.Response
+			resp.Header.Add(fasthttp.HeaderVary, "Accept-Encoding")
+			if ctx.IsHead() || resp.IsBodyStream() || len(resp.Header.ContentEncoding()) > 0 {
+				return
+			}
+			if resp.StatusCode() == fasthttp.StatusNoContent || resp.StatusCode() == fasthttp.StatusNotModified {
+				return
+			}
+			if len(resp.Body()) < minSize || !compressible(resp.Header.ContentType()) {
+				return
+			}
+
+			encoding := Negotiate(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptEncoding)))
+			if encoding == "" {
+				return
+			}
+
+			var body []byte
+			switch encoding {
+			case Zstd:
+				body = fasthttp.AppendZstdBytesLevel(nil, resp.Body(), fasthttp.CompressZstdDefault)
+			case Brotli:
+				body = fasthttp.AppendBrotliBytesLevel(nil, resp.Body(), fasthttp.CompressBrotliDefaultCompression)
+			case Gzip:
+				body = fasthttp.AppendGzipBytesLevel(nil, resp.Body(), fasthttp.CompressDefaultCompression)
+			}
+
+			resp.SetBodyRaw(body)
+			resp.Header.SetContentEncoding(encoding)
+			if etag := string(resp.Header.Peek(fasthttp.HeaderETag)); strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`) {
+				resp.Header.Set(fasthttp.HeaderETag, strings.TrimSuffix(etag, `"`)+"-"+encoding+`"`)
+			}
+		}
+	}
+}
+
+// Negotiate picks the encoding for an Accept-Encoding header, or "" when the
+// client accepts none of the supported encodings
+func Negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range preference {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// ETagMatches reports whether an If-None-Match header matches etag, also for
+// ETags the middleware extended with an encoding
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+		for _, encoding := range preference {
+			if candidate == strings.TrimSuffix(etag, `"`)+"-"+encoding+`"` {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func compressible(contentType []byte) bool {
+	for _, prefix := range compressibleTypes {
+		if bytes.HasPrefix(contentType, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,356 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/auth"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/certs"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/compress"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/cors"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/logger"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/notify"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/ratelimit"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/tracing"
+)
+
+// Config holds every setting of the controller and the API server.
+//
+// Settings are layered: defaults, then the configuration file, then
+// environment variables, then command-line flags. Keys in files and
+// environment variables follow the json tags (or the field name in lower
+// camel case for types from other packages): server.tls.certFile in a file is
+// K8S_CONTROLLER_SERVER_TLS_CERT_FILE in the environment.
+type Config struct {
+	// Kubeconfig is the kubeconfig file; empty means $KUBECONFIG or ~/.kube/config
+	Kubeconfig string           `json:"kubeconfig"`
+	Log        LogConfig        `json:"log"`
+	Tracing    tracing.Config   `json:"tracing"`
+	Controller ControllerConfig `json:"controller"`
+	Server     ServerConfig     `json:"server"`
+	Alerts     AlertsConfig     `json:"alerts"`
+}
+
+// LogConfig holds the log level and log sampling and redaction settings
+type LogConfig struct {
+	Level     string                 `json:"level"`
+	Sampling  logger.SamplingConfig  `json:"sampling"`
+	Redaction logger.RedactionConfig `json:"redaction"`
+}
+
+// ControllerConfig holds settings of the controller command
+type ControllerConfig struct {
+	Namespace string `json:"namespace"`
+	// LabelSelector limits the deployments shown, e.g. app=web,tier!=cache
+	LabelSelector string `json:"labelSelector"`
+	Watch         bool   `json:"watch"`
+	// ChunkSize is the number of deployments fetched per API request
+	ChunkSize int64 `json:"chunkSize"`
+	// HealthPort serves /health, the probes and /metrics in watch mode (0 disables)
+	HealthPort int `json:"healthPort"`
+	// IgnoreStatus hides changes to .status in watch mode, such as replica
+	// counts moving during a rollout
+	IgnoreStatus bool `json:"ignoreStatus"`
+	// Monitors reconciles DeploymentMonitor objects in watch mode
+	Monitors bool `json:"monitors"`
+	// CrossNamespaceMonitors are the namespaces whose DeploymentMonitors may
+	// select deployments in other namespaces. The controller reads those with
+	// its own permissions, so list only namespaces where just administrators
+	// can create monitors.
+	CrossNamespaceMonitors []string `json:"crossNamespaceMonitors"`
+}
+
+// AlertsConfig holds the alerting rules settings of the server and of the
+// controller in watch mode
+type AlertsConfig struct {
+	// RulesFile is a YAML file of alerting rules; empty disables alerting
+	RulesFile string `json:"rulesFile"`
+	// Namespace is evaluated by the rules; empty means all namespaces
+	Namespace string `json:"namespace"`
+	// Interval is how often the rules are evaluated
+	Interval time.Duration `json:"interval"`
+	// NotificationsFile is a YAML file of notification sinks; empty sends
+	// no notifications
+	NotificationsFile string `json:"notificationsFile"`
+	// DeadLetterFile receives notifications that could not be delivered as
+	// JSON lines; empty only logs them
+	DeadLetterFile string `json:"deadLetterFile"`
+}
+
+// ServerConfig holds settings of the API server
+type ServerConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// HealthPort serves only /health and /metrics over plain HTTP (0 disables)
+	HealthPort int `json:"healthPort"`
+	// HTTPRedirectPort redirects plain HTTP to HTTPS (0 disables)
+	HTTPRedirectPort int `json:"httpRedirectPort"`
+
+	StatusTimeout time.Duration `json:"statusTimeout"`
+	// MaxStatusTimeout caps the timeout query parameter of /api/v1/status
+	MaxStatusTimeout     time.Duration `json:"maxStatusTimeout"`
+	ListPageSize         int64         `json:"listPageSize"`
+	ExposeInternalErrors bool          `json:"exposeInternalErrors"`
+
+	TLS            TLSConfig            `json:"tls"`
+	CORS           cors.Config          `json:"cors"`
+	RateLimits     RateLimitConfig      `json:"rateLimits"`
+	Compression    CompressionConfig    `json:"compression"`
+	Authentication AuthenticationConfig `json:"authentication"`
+	Authorization  AuthorizationConfig  `json:"authorization"`
+}
+
+// TLSConfig holds the serving certificate settings
+type TLSConfig struct {
+	CertFile       string `json:"certFile"`
+	PrivateKeyFile string `json:"privateKeyFile"`
+	MinVersion     string `json:"minVersion"`
+	ClientCAFile   string `json:"clientCAFile"`
+	// SelfSigned serves a generated certificate (development only)
+	SelfSigned bool `json:"selfSigned"`
+}
+
+// Enabled reports whether the API server listens with HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.SelfSigned
+}
+
+// RateLimitConfig holds token buckets per route group as RATE/UNIT[:BURST]
+type RateLimitConfig struct {
+	PerIP       map[string]string `json:"perIP"`
+	PerUser     map[string]string `json:"perUser"`
+	MaxInFlight int               `json:"maxInFlight"`
+}
+
+// CompressionConfig holds response compression settings
+type CompressionConfig struct {
+	Enabled bool `json:"enabled"`
+	MinSize int  `json:"minSize"`
+}
+
+// AuthenticationConfig holds the authentication methods for /api endpoints
+type AuthenticationConfig struct {
+	// TokenFile is a static token CSV file
+	TokenFile            string        `json:"tokenFile"`
+	TokenWebhook         bool          `json:"tokenWebhook"`
+	TokenWebhookCacheTTL time.Duration `json:"tokenWebhookCacheTTL"`
+	APIAudiences         []string      `json:"apiAudiences"`
+}
+
+// AuthorizationConfig holds the per-user authorization settings
+type AuthorizationConfig struct {
+	Mode       string        `json:"mode"`
+	AllowedTTL time.Duration `json:"allowedTTL"`
+	DeniedTTL  time.Duration `json:"deniedTTL"`
+}
+
+// DefaultRateLimits are the limits of the route groups; groups left out of a
+// rate limit setting keep their default
+var DefaultRateLimits = map[string]string{
+	"api":    "20/s:40",
+	"status": "2/s:5",
+}
+
+// Default returns the built-in defaults. Internal errors are hidden and logs
+// are redacted by default when ENV is prod or production.
+func Default() *Config {
+	production := os.Getenv("ENV") == "prod" || os.Getenv("ENV") == "production"
+
+	return &Config{
+		Log: LogConfig{
+			Level: logger.DefaultLevel(),
+			Sampling: logger.SamplingConfig{
+				Period:         time.Second,
+				ReportInterval: time.Minute,
+			},
+			Redaction: logger.DefaultRedactionConfig(),
+		},
+		Tracing: tracing.Config{
+			Exporter:    tracing.ExporterNone,
+			FilePath:    "traces.json",
+			SampleRatio: 1.0,
+		},
+		Controller: ControllerConfig{
+			Namespace: "default",
+			ChunkSize: 500,
+		},
+		Server: ServerConfig{
+			Host:                 "0.0.0.0",
+			Port:                 8080,
+			StatusTimeout:        10 * time.Second,
+			MaxStatusTimeout:     time.Minute,
+			ListPageSize:         500,
+			ExposeInternalErrors: !production,
+			TLS: TLSConfig{
+				MinVersion: "VersionTLS12",
+			},
+			CORS: cors.DefaultConfig(),
+			RateLimits: RateLimitConfig{
+				PerIP:       copyMap(DefaultRateLimits),
+				PerUser:     copyMap(DefaultRateLimits),
+				MaxInFlight: 100,
+			},
+			Compression: CompressionConfig{
+				Enabled: true,
+				MinSize: compress.DefaultMinSize,
+			},
+			Authentication: AuthenticationConfig{
+				TokenWebhookCacheTTL: 2 * time.Minute,
+			},
+			Authorization: AuthorizationConfig{
+				Mode:       auth.ModeAlwaysAllow,
+				AllowedTTL: 5 * time.Minute,
+				DeniedTTL:  30 * time.Second,
+			},
+		},
+		Alerts: AlertsConfig{
+			Interval: 30 * time.Second,
+		},
+	}
+}
+
+// Validate checks every setting and reports all problems at once, each
+// prefixed with the key of the setting
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(key string, err error) {
+		if err != nil {
+			problems = append(problems, key+": "+err.Error())
+		}
+	}
+
+	check("log.level", oneOf(c.Log.Level, logger.Levels...))
+	check("log.sampling", c.Log.Sampling.Validate())
+	check("tracing.exporter", oneOf(c.Tracing.Exporter, tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterFile))
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		check("tracing.sampleRatio", fmt.Errorf("must be between 0 and 1: %v", c.Tracing.SampleRatio))
+	}
+
+	if _, err := labels.Parse(c.Controller.LabelSelector); err != nil {
+		check("controller.labelSelector", err)
+	}
+	if c.Controller.ChunkSize <= 0 {
+		check("controller.chunkSize", fmt.Errorf("must be positive: %d", c.Controller.ChunkSize))
+	}
+	check("controller.healthPort", portInRange(c.Controller.HealthPort, true))
+
+	s := c.Server
+	check("server.port", portInRange(s.Port, false))
+	check("server.healthPort", portInRange(s.HealthPort, true))
+	check("server.httpRedirectPort", portInRange(s.HTTPRedirectPort, true))
+	if s.HealthPort != 0 && s.HealthPort == s.Port {
+		check("server.healthPort", fmt.Errorf("must differ from server.port"))
+	}
+	if s.HTTPRedirectPort != 0 && (s.HTTPRedirectPort == s.Port || s.HTTPRedirectPort == s.HealthPort) {
+		check("server.httpRedirectPort", fmt.Errorf("must differ from server.port and server.healthPort"))
+	}
+	if s.StatusTimeout <= 0 {
+		check("server.statusTimeout", fmt.Errorf("must be positive: %s", s.StatusTimeout))
+	}
+	if s.MaxStatusTimeout < s.StatusTimeout {
+		check("server.maxStatusTimeout", fmt.Errorf("must not be less than server.statusTimeout (%s): %s", s.StatusTimeout, s.MaxStatusTimeout))
+	}
+	if s.ListPageSize <= 0 {
+		check("server.listPageSize", fmt.Errorf("must be positive: %d", s.ListPageSize))
+	}
+
+	_, err := certs.ParseTLSVersion(s.TLS.MinVersion)
+	check("server.tls.minVersion", err)
+	if (s.TLS.CertFile == "") != (s.TLS.PrivateKeyFile == "") {
+		check("server.tls", fmt.Errorf("certFile and privateKeyFile must be set together"))
+	}
+	if s.TLS.CertFile != "" && s.TLS.SelfSigned {
+		check("server.tls.selfSigned", fmt.Errorf("cannot be combined with certFile"))
+	}
+	if !s.TLS.Enabled() && s.TLS.ClientCAFile != "" {
+		check("server.tls.clientCAFile", fmt.Errorf("requires certFile or selfSigned"))
+	}
+	if !s.TLS.Enabled() && s.HTTPRedirectPort != 0 {
+		check("server.httpRedirectPort", fmt.Errorf("requires server.tls.certFile or server.tls.selfSigned"))
+	}
+
+	check("server.cors", s.CORS.Validate())
+	check("server.rateLimits.perIP", validateRateLimits(s.RateLimits.PerIP))
+	check("server.rateLimits.perUser", validateRateLimits(s.RateLimits.PerUser))
+	if s.RateLimits.MaxInFlight < 0 {
+		check("server.rateLimits.maxInFlight", fmt.Errorf("must not be negative: %d", s.RateLimits.MaxInFlight))
+	}
+	if s.Compression.MinSize < 0 {
+		check("server.compression.minSize", fmt.Errorf("must not be negative: %d", s.Compression.MinSize))
+	}
+
+	check("server.authorization.mode", oneOf(s.Authorization.Mode, auth.ModeAlwaysAllow, auth.ModeSubjectAccessReview))
+	authenticated := s.Authentication.TokenFile != "" || s.Authentication.TokenWebhook || s.TLS.ClientCAFile != ""
+	if s.Authorization.Mode == auth.ModeSubjectAccessReview && !authenticated {
+		check("server.authorization.mode", fmt.Errorf("%s requires an authentication method", auth.ModeSubjectAccessReview))
+	}
+
+	if c.Alerts.RulesFile != "" {
+		_, err := alerts.LoadRules(c.Alerts.RulesFile)
+		check("alerts.rulesFile", err)
+	}
+	if c.Alerts.NotificationsFile != "" {
+		_, err := notify.Load(c.Alerts.NotificationsFile)
+		check("alerts.notificationsFile", err)
+	}
+	if c.Alerts.Interval <= 0 {
+		check("alerts.interval", fmt.Errorf("must be positive: %s", c.Alerts.Interval))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// validateRateLimits checks group names and limit syntax
+func validateRateLimits(limits map[string]string) error {
+	groups := make([]string, 0, len(limits))
+	for group := range limits {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		if _, ok := DefaultRateLimits[group]; !ok {
+			return fmt.Errorf("unknown route group %q (expected api or status)", group)
+		}
+		if _, err := ratelimit.ParseLimit(limits[group]); err != nil {
+			return fmt.Errorf("%s: %v", group, err)
+		}
+	}
+	return nil
+}
+
+func portInRange(port int, optional bool) error {
+	if optional && port == 0 {
+		return nil
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("must be between 1 and 65535: %d", port)
+	}
+	return nil
+}
+
+func oneOf(value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown value %q (expected one of %s)", value, strings.Join(allowed, ", "))
+}
+
+func copyMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
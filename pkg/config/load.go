@@ -0,0 +1,354 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvPrefix starts the names of environment variables that override settings
+const EnvPrefix = "K8S_CONTROLLER"
+
+// EnvConfigFile names the configuration file when --config is not given
+const EnvConfigFile = EnvPrefix + "_CONFIG"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadFile overrides cfg with the settings in a YAML, JSON or TOML file; the
+// format follows the extension. Settings missing from the file keep their
+// value, unknown keys are an error.
+func LoadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	values := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("unsupported config file extension %q (expected .yaml, .yml, .json or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	if err := decodeMap(values, reflect.ValueOf(cfg).Elem(), ""); err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// LoadEnv overrides cfg with EnvPrefix variables from environ (as returned by
+// os.Environ). Lists are comma-separated or JSON arrays, and maps are
+// key=value pairs. Lists of fields tagged list:"json", whose items may
+// contain commas, must be JSON arrays. Variables that match no setting are
+// an error, so typos do not go unnoticed.
+func LoadEnv(environ []string, cfg *Config) error {
+	type setting struct {
+		field reflect.StructField
+		value reflect.Value
+	}
+	settings := make(map[string]setting)
+	walk(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.StructField, value reflect.Value) {
+		settings[EnvName(key)] = setting{field: field, value: value}
+	})
+
+	var problems []string
+	for _, entry := range environ {
+		name, raw, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix+"_") || name == EnvConfigFile {
+			continue
+		}
+		s, ok := settings[name]
+		if !ok {
+			problems = append(problems, name+": no such setting")
+			continue
+		}
+		var err error
+		if s.value.Kind() == reflect.Slice {
+			err = setList(s.value, raw, s.field.Tag.Get("list") == "json")
+		} else {
+			err = setString(s.value, raw)
+		}
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid environment: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// EnvName returns the environment variable for a setting key such as
+// server.tls.clientCAFile (K8S_CONTROLLER_SERVER_TLS_CLIENT_CA_FILE)
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for _, part := range strings.Split(key, ".") {
+		b.WriteByte('_')
+		runes := []rune(part)
+		for i, r := range runes {
+			if i > 0 && unicode.IsUpper(r) {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || nextLower {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// walk calls fn for every setting, that is every field that is not a struct
+func walk(v reflect.Value, prefix string, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := join(prefix, keyName(field))
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), key, fn)
+			continue
+		}
+		fn(key, field, v.Field(i))
+	}
+}
+
+// decodeMap sets the fields of v from a parsed file section
+func decodeMap(values map[string]interface{}, v reflect.Value, prefix string) error {
+	fields := make(map[string]int)
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.IsExported() {
+			fields[keyName(field)] = i
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := join(prefix, key)
+		i, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown setting %q", path)
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			section, ok := values[key].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: expected a section, got %T", path, values[key])
+			}
+			if err := decodeMap(section, field, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setValue(field, values[key]); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// setValue sets a setting from a value parsed from YAML, JSON or TOML.
+// Strings are converted like environment variables, so durations are
+// written as "30s" and numbers may be quoted.
+func setValue(v reflect.Value, raw interface{}) error {
+	if s, ok := raw.(string); ok {
+		return setString(v, s)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %v", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		if v.Type() == durationType {
+			return fmt.Errorf("expected a duration such as \"30s\", got %v", raw)
+		}
+		n, ok := toFloat(raw)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("expected an integer, got %v", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, ok := toFloat(raw)
+		if !ok {
+			return fmt.Errorf("expected a number, got %v", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list, got %v", raw)
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(list.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %v", i, err)
+			}
+		}
+		v.Set(list)
+	case reflect.Map:
+		entries, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a map, got %v", raw)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(entries))
+		for key, entry := range entries {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, entry); err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// setList sets a list from a JSON array, or from comma-separated items
+// unless jsonOnly is set
+func setList(v reflect.Value, s string, jsonOnly bool) error {
+	var items []interface{}
+	if err := json.Unmarshal([]byte(s), &items); err == nil {
+		if items == nil {
+			items = []interface{}{}
+		}
+		return setValue(v, items)
+	} else if jsonOnly {
+		return fmt.Errorf(`expected a JSON array such as ["a", "b"], got %q`, s)
+	}
+	return setString(v, s)
+}
+
+// setString sets a setting from its string form
+func setString(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected a boolean, got %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("expected a duration such as \"30s\", got %q", s)
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", s)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var items []interface{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if items == nil {
+			items = []interface{}{}
+		}
+		return setValue(v, items)
+	case reflect.Map:
+		entries := make(map[string]interface{})
+		for _, pair := range strings.Split(s, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value pairs, got %q", pair)
+			}
+			entries[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return setValue(v, entries)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+func toFloat(raw interface{}) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// keyName returns the key of a field: its json tag, or the field name in
+// lower camel case (ClientCAFile becomes clientCAFile, TLS becomes tls)
+func keyName(field reflect.StructField) string {
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+		return tag
+	}
+
+	runes := []rune(field.Name)
+	for i := range runes {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
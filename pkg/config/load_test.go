@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a config file named name into a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+controller:
+  namespace: from-file
+server:
+  port: 9000
+  statusTimeout: 5s
+  cors:
+    allowedOrigins: [https://file.example.com]
+log:
+  redaction:
+    keyPatterns: ["*token*"]
+`)
+	cfg := Default()
+	if err := LoadFile(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	err := LoadEnv([]string{
+		"K8S_CONTROLLER_SERVER_PORT=9100",
+		"K8S_CONTROLLER_SERVER_CORS_ALLOWED_ORIGINS=https://a.example.com, https://b.example.com",
+		"HOME=/root",
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := Default()
+	tests := []struct {
+		key       string
+		got, want interface{}
+	}{
+		{"controller.chunkSize (default)", cfg.Controller.ChunkSize, defaults.Controller.ChunkSize},
+		{"controller.namespace (file)", cfg.Controller.Namespace, "from-file"},
+		{"server.statusTimeout (file)", cfg.Server.StatusTimeout, 5 * time.Second},
+		{"log.redaction.keyPatterns (file)", cfg.Log.Redaction.KeyPatterns, []string{"*token*"}},
+		{"server.port (environment over file)", cfg.Server.Port, 9100},
+		{"server.cors.allowedOrigins (environment over file)", cfg.Server.CORS.AllowedOrigins, []string{"https://a.example.com", "https://b.example.com"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadEnvLists(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		get   func(c *Config) []string
+		want  []string
+		fails bool
+	}{
+		{
+			name: "comma-separated",
+			env:  "K8S_CONTROLLER_LOG_REDACTION_KEY_PATTERNS=*token*, password",
+			get:  func(c *Config) []string { return c.Log.Redaction.KeyPatterns },
+			want: []string{"*token*", "password"},
+		},
+		{
+			name: "JSON array",
+			env:  `K8S_CONTROLLER_LOG_REDACTION_KEY_PATTERNS=["a,b", "c"]`,
+			get:  func(c *Config) []string { return c.Log.Redaction.KeyPatterns },
+			want: []string{"a,b", "c"},
+		},
+		{
+			name: "empty JSON array",
+			env:  `K8S_CONTROLLER_SERVER_CORS_ALLOWED_ORIGINS=[]`,
+			get:  func(c *Config) []string { return c.Server.CORS.AllowedOrigins },
+			want: []string{},
+		},
+		{
+			name: "regular expression with a comma",
+			env:  `K8S_CONTROLLER_LOG_REDACTION_VALUE_PATTERNS=["[0-9]{3,4}"]`,
+			get:  func(c *Config) []string { return c.Log.Redaction.ValuePatterns },
+			want: []string{"[0-9]{3,4}"},
+		},
+		{
+			name: "regular expressions",
+			env:  `K8S_CONTROLLER_LOG_REDACTION_VALUE_PATTERNS=["AKIA[0-9A-Z]{16}", "(?i)pin=(?P<secret>\\d{4,6})"]`,
+			get:  func(c *Config) []string { return c.Log.Redaction.ValuePatterns },
+			want: []string{"AKIA[0-9A-Z]{16}", `(?i)pin=(?P<secret>\d{4,6})`},
+		},
+		{
+			name:  "regular expression outside an array",
+			env:   "K8S_CONTROLLER_LOG_REDACTION_VALUE_PATTERNS=[0-9]{3,4}",
+			fails: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			err := LoadEnv([]string{tt.env}, cfg)
+			if tt.fails {
+				if err == nil || !strings.Contains(err.Error(), "JSON array") {
+					t.Fatalf("LoadEnv() = %v, want an error asking for a JSON array", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.get(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	err := LoadEnv([]string{
+		"K8S_CONTROLLER_SERVER_PROT=80",
+		"K8S_CONTROLLER_SERVER_STATUS_TIMEOUT=10",
+		"K8S_CONTROLLER_CONFIG=/etc/config.yaml",
+	}, Default())
+	if err == nil {
+		t.Fatal("LoadEnv() accepted an unknown variable and an invalid duration")
+	}
+	for _, want := range []string{"K8S_CONTROLLER_SERVER_PROT: no such setting", "K8S_CONTROLLER_SERVER_STATUS_TIMEOUT: expected a duration"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"unknown key", "c.yaml", "server: {prot: 80}", `unknown setting "server.prot"`},
+		{"wrong type", "c.json", `{"server": {"port": "eighty"}}`, "server.port: expected an integer"},
+		{"extension", "c.ini", "port=80", "unsupported config file extension"},
+		{"TOML", "c.toml", "[server]\nstatusTimeout = 5", "server.statusTimeout: expected a duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LoadFile(writeFile(t, tt.file, tt.content), Default())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFile() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
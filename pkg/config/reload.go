@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay debounces bursts of file events, e.g. an editor writing a
+// temporary file and renaming it
+const reloadDelay = 500 * time.Millisecond
+
+// Watch calls reload when the config file changes or the process receives
+// SIGHUP, until ctx is cancelled. With an empty path only SIGHUP triggers a
+// reload. The parent directory is watched, so files replaced by a rename and
+// ConfigMap volumes, which swap a ..data symlink, are noticed.
+func Watch(ctx context.Context, path string, reload func(trigger string)) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	if path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			signal.Stop(hangup)
+			return fmt.Errorf("failed to create file watcher: %v", err)
+		}
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			signal.Stop(hangup)
+			return fmt.Errorf("failed to watch %s: %v", filepath.Dir(path), err)
+		}
+		events, errs = watcher.Events, watcher.Errors
+		go func() {
+			<-ctx.Done()
+			watcher.Close()
+		}()
+	}
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		var timer <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				reload("SIGHUP")
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				name := filepath.Base(event.Name)
+				if event.Name == path || name == filepath.Base(path) || name == "..data" {
+					timer = time.After(reloadDelay)
+				}
+			case _, ok := <-errs:
+				if !ok {
+					errs = nil
+				}
+			case <-timer:
+				timer = nil
+				reload("file")
+			}
+		}
+	}()
+	return nil
+}
+
+// Clone returns a deep copy of c
+func (c *Config) Clone() *Config {
+	data, err := json.Marshal(c)
+	if err != nil {
+		panic(fmt.Sprintf("config: failed to copy configuration: %v", err))
+	}
+	clone := &Config{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		panic(fmt.Sprintf("config: failed to copy configuration: %v", err))
+	}
+	return clone
+}
+
+// Diff returns the keys of the settings that differ between a and b
+func Diff(a, b *Config) []string {
+	values := make(map[string]reflect.Value)
+	walk(reflect.ValueOf(a).Elem(), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		values[key] = value
+	})
+
+	var changed []string
+	walk(reflect.ValueOf(b).Elem(), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		if !equal(values[key], value) {
+			changed = append(changed, key)
+		}
+	})
+	return changed
+}
+
+// Overlay copies the settings with the given keys from src to dst
+func Overlay(dst, src *Config, keys []string) {
+	values := make(map[string]reflect.Value)
+	walk(reflect.ValueOf(src).Elem(), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		values[key] = value
+	})
+
+	overlay := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		overlay[key] = true
+	}
+	walk(reflect.ValueOf(dst).Elem(), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		if overlay[key] {
+			value.Set(values[key])
+		}
+	})
+}
+
+// equal compares two settings; an empty list or map equals a nil one
+func equal(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
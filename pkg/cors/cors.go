@@ -0,0 +1,295 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// Config describes which cross-origin requests browsers may make
+type Config struct {
+	// AllowedOrigins are exact origins (https://app.example.com), wildcard
+	// subdomains (https://*.example.com) or "*" for any origin. No origins
+	// means cross-origin requests are not allowed.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// DefaultConfig allows no origins, with methods and headers that suit the API
+// once origins are added
+func DefaultConfig() Config {
+	return Config{
+		AllowedMethods: []string{fasthttp.MethodGet, fasthttp.MethodHead, fasthttp.MethodPost},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// Validate checks the configuration for mistakes browsers would reject
+func (c Config) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("the wildcard origin \"*\" cannot be combined with credentials")
+			}
+			continue
+		}
+		if !strings.Contains(origin, "://") {
+			return fmt.Errorf("invalid origin %q: expected scheme://host[:port]", origin)
+		}
+		if strings.Count(origin, "*") > 1 || (strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")) {
+			return fmt.Errorf("invalid origin %q: wildcards are only supported as scheme://*.domain", origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+	return nil
+}
+
+// Policy applies a CORS configuration to requests. The configuration can be
+// replaced at runtime with Configure.
+type Policy struct {
+	rules atomic.Pointer[rules]
+
+	// Rejected handles preflight requests that are not allowed and unsafe
+	// requests from origins that are not allowed. The status code is set to
+	// 403 before it is called.
+	Rejected func(ctx *fasthttp.RequestCtx, err error)
+}
+
+// rules is a compiled Config
+type rules struct {
+	anyOrigin bool
+	origins   map[string]bool
+	wildcards []wildcard
+	methods   map[string]bool
+	headers   map[string]bool
+
+	allowCredentials bool
+	maxAge           string
+}
+
+// wildcard matches origins such as https://*.example.com
+type wildcard struct {
+	prefix string
+	suffix string
+}
+
+func (w wildcard) match(origin string) bool {
+	if len(origin) <= len(w.prefix)+len(w.suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	subdomain := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// New creates a policy from a validated configuration
+func New(cfg Config) (*Policy, error) {
+	p := &Policy{}
+	if err := p.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Configure validates cfg and applies it to subsequent requests
+func (p *Policy) Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	r := &rules{
+		origins:          make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			r.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			i := strings.Index(origin, "*")
+			r.wildcards = append(r.wildcards, wildcard{prefix: origin[:i], suffix: origin[i+1:]})
+		default:
+			r.origins[origin] = true
+		}
+	}
+	for _, method := range cfg.AllowedMethods {
+		r.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	if cfg.MaxAge > 0 {
+		r.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	p.rules.Store(r)
+	return nil
+}
+
+// OriginAllowed reports whether requests from origin may read responses
+func (p *Policy) OriginAllowed(origin string) bool {
+	return p.rules.Load().originAllowed(origin)
+}
+
+func (r *rules) originAllowed(origin string) bool {
+	if r.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if r.origins[origin] {
+		return true
+	}
+	for _, w := range r.wildcards {
+		if w.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns router middleware applying the policy. allowed returns the
+// methods registered for a path, so preflight requests only succeed for
+// routes that exist.
+func (p *Policy) Middleware(allowed func(path string) []string) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			// Responses differ per origin, so caches must key on it
+			ctx.Response.Header.Add(fasthttp.HeaderVary, "Origin")
+
+			origin := string(ctx.Request.Header.Peek("Origin"))
+			if origin == "" {
+				next(ctx)
+				return
+			}
+
+			// Rules are loaded once so a concurrent Configure cannot mix them
+			r := p.rules.Load()
+			requestMethod := string(ctx.Request.Header.Peek("Access-Control-Request-Method"))
+			if ctx.IsOptions() && requestMethod != "" {
+				p.preflight(ctx, r, origin, requestMethod, allowed(string(ctx.Path())), next)
+				return
+			}
+
+			if !r.originAllowed(origin) {
+				// Browsers send simple cross-site POSTs without a preflight,
+				// so unsafe methods are refused rather than merely unreadable
+				if !isSafeMethod(string(ctx.Method())) {
+					p.reject(ctx, fmt.Errorf("origin %s is not allowed", origin))
+					return
+				}
+				next(ctx)
+				return
+			}
+
+			r.setOrigin(ctx, origin)
+			next(ctx)
+		}
+	}
+}
+
+// preflight answers an OPTIONS request sent by a browser before a
+// cross-origin request
+func (p *Policy) preflight(ctx *fasthttp.RequestCtx, r *rules, origin, method string, routeMethods []string, next fasthttp.RequestHandler) {
+	ctx.Response.Header.Add(fasthttp.HeaderVary, "Access-Control-Request-Method")
+	ctx.Response.Header.Add(fasthttp.HeaderVary, "Access-Control-Request-Headers")
+
+	// Unknown paths get the router's normal 404
+	if routeMethods == nil {
+		next(ctx)
+		return
+	}
+
+	if !r.originAllowed(origin) {
+		p.reject(ctx, fmt.Errorf("origin %s is not allowed", origin))
+		return
+	}
+
+	method = strings.ToUpper(method)
+	if !r.methods[method] || !contains(routeMethods, method) {
+		p.reject(ctx, fmt.Errorf("method %s is not allowed for %s", method, ctx.Path()))
+		return
+	}
+
+	var headers []string
+	for _, header := range strings.Split(string(ctx.Request.Header.Peek("Access-Control-Request-Headers")), ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header == "" {
+			continue
+		}
+		if !r.headers[header] {
+			p.reject(ctx, fmt.Errorf("header %s is not allowed", header))
+			return
+		}
+		headers = append(headers, header)
+	}
+
+	var methods []string
+	for _, m := range routeMethods {
+		if r.methods[m] {
+			methods = append(methods, m)
+		}
+	}
+
+	r.setOrigin(ctx, origin)
+	ctx.Response.Header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if r.maxAge != "" {
+		ctx.Response.Header.Set("Access-Control-Max-Age", r.maxAge)
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// setOrigin echoes an allowed origin. The origin is echoed even for "*" so
+// responses never differ from what Vary: Origin promises.
+func (r *rules) setOrigin(ctx *fasthttp.RequestCtx, origin string) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", origin)
+	if r.allowCredentials {
+		ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *Policy) reject(ctx *fasthttp.RequestCtx, err error) {
+	ctx.SetStatusCode(fasthttp.StatusForbidden)
+	if p.Rejected != nil {
+		p.Rejected(ctx, err)
+		return
+	}
+	ctx.Error(err.Error(), fasthttp.StatusForbidden)
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case fasthttp.MethodGet, fasthttp.MethodHead, fasthttp.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
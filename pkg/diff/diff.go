@@ -0,0 +1,353 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// ignoredAnnotations change with every apply or rollout and say nothing new
+var ignoredAnnotations = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+}
+
+// Change is one meaningful difference between two versions of a deployment
+type Change struct {
+	// Field names the setting, e.g. spec.replicas,
+	// containers[web].image or labels[app]
+	Field string      `json:"field"`
+	Kind  string      `json:"kind"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+	// Status is set for changes the cluster made in .status
+	Status bool `json:"status,omitempty"`
+}
+
+// Operation is one RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Options select the changes that are reported
+type Options struct {
+	// IgnoreStatus drops changes to .status, such as replica counts moving
+	// during a rollout
+	IgnoreStatus bool
+}
+
+// Result holds the changes between two versions of a deployment and the JSON
+// Patch that turns the old version into the new one for those fields
+type Result struct {
+	Changes []Change    `json:"changes"`
+	Patch   []Operation `json:"patch"`
+}
+
+// Empty reports whether nothing meaningful changed
+func (r Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// Deployments compares the fields of two versions of a deployment that
+// matter to people: replicas, container images, env and resources, labels,
+// annotations, replica status and conditions. Bookkeeping such as
+// resourceVersion, managedFields or condition timestamps is ignored.
+func Deployments(old, new *appsv1.Deployment, opts Options) Result {
+	d := &differ{}
+
+	d.scalar("spec.replicas", "/spec/replicas", replicas(old.Spec.Replicas), replicas(new.Spec.Replicas), false)
+	d.containers("initContainers", "/spec/template/spec/initContainers", old.Spec.Template.Spec.InitContainers, new.Spec.Template.Spec.InitContainers)
+	d.containers("containers", "/spec/template/spec/containers", old.Spec.Template.Spec.Containers, new.Spec.Template.Spec.Containers)
+	d.stringMap("labels", "/metadata/labels", old.Labels, new.Labels, nil)
+	d.stringMap("annotations", "/metadata/annotations", old.Annotations, new.Annotations, ignoredAnnotations)
+
+	if !opts.IgnoreStatus {
+		d.scalar("status.replicas", "/status/replicas", old.Status.Replicas, new.Status.Replicas, true)
+		d.scalar("status.updatedReplicas", "/status/updatedReplicas", old.Status.UpdatedReplicas, new.Status.UpdatedReplicas, true)
+		d.scalar("status.readyReplicas", "/status/readyReplicas", old.Status.ReadyReplicas, new.Status.ReadyReplicas, true)
+		d.scalar("status.availableReplicas", "/status/availableReplicas", old.Status.AvailableReplicas, new.Status.AvailableReplicas, true)
+		d.scalar("status.unavailableReplicas", "/status/unavailableReplicas", old.Status.UnavailableReplicas, new.Status.UnavailableReplicas, true)
+		d.conditions(old.Status.Conditions, new.Status.Conditions)
+	}
+
+	return d.result()
+}
+
+// differ collects changes and patch operations. Operations are applied in
+// order: replacements at the old positions, then additions (appended to
+// lists), then removals from the highest list position down, so that no
+// operation shifts a position a later one refers to.
+type differ struct {
+	changes  []Change
+	replaces []Operation
+	adds     []Operation
+	removes  []Operation
+	// created holds the paths of containers added to the patch
+	created map[string]bool
+}
+
+func (d *differ) result() Result {
+	sort.SliceStable(d.removes, func(i, j int) bool {
+		return pathAfter(d.removes[i].Path, d.removes[j].Path)
+	})
+
+	patch := make([]Operation, 0, len(d.replaces)+len(d.adds)+len(d.removes))
+	patch = append(patch, d.replaces...)
+	patch = append(patch, d.adds...)
+	patch = append(patch, d.removes...)
+	return Result{Changes: d.changes, Patch: patch}
+}
+
+func (d *differ) change(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// ensure adds an empty container at path when the old version has none, so
+// that members can be added to it
+func (d *differ) ensure(path string, exists bool, empty interface{}) {
+	if exists || d.created[path] {
+		return
+	}
+	if d.created == nil {
+		d.created = make(map[string]bool)
+	}
+	d.created[path] = true
+	d.adds = append(d.adds, Operation{Op: "add", Path: path, Value: empty})
+}
+
+func (d *differ) scalar(field, path string, old, new interface{}, status bool) {
+	if old == new {
+		return
+	}
+	d.change(Change{Field: field, Kind: Changed, Old: old, New: new, Status: status})
+	d.replaces = append(d.replaces, Operation{Op: "replace", Path: path, Value: new})
+}
+
+func (d *differ) containers(field, path string, old, new []corev1.Container) {
+	oldIndex := make(map[string]int, len(old))
+	for i, c := range old {
+		oldIndex[c.Name] = i
+	}
+
+	for _, n := range new {
+		i, ok := oldIndex[n.Name]
+		if !ok {
+			d.change(Change{Field: fmt.Sprintf("%s[%s]", field, n.Name), Kind: Added, New: n.Image})
+			d.ensure(path, len(old) > 0, []corev1.Container{})
+			d.adds = append(d.adds, Operation{Op: "add", Path: path + "/-", Value: n})
+			continue
+		}
+		delete(oldIndex, n.Name)
+
+		o := old[i]
+		name := fmt.Sprintf("%s[%s]", field, n.Name)
+		at := path + "/" + strconv.Itoa(i)
+		if o.Image != n.Image {
+			d.change(Change{Field: name + ".image", Kind: Changed, Old: o.Image, New: n.Image})
+			d.replaces = append(d.replaces, Operation{Op: "replace", Path: at + "/image", Value: n.Image})
+		}
+		d.env(name+".env", at+"/env", o.Env, n.Env)
+		d.resources(name+".resources.requests", at+"/resources", "requests", o.Resources.Requests, n.Resources.Requests)
+		d.resources(name+".resources.limits", at+"/resources", "limits", o.Resources.Limits, n.Resources.Limits)
+	}
+
+	for _, name := range leftover(oldIndex) {
+		i := oldIndex[name]
+		d.change(Change{Field: fmt.Sprintf("%s[%s]", field, name), Kind: Removed, Old: old[i].Image})
+		d.removes = append(d.removes, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+}
+
+func (d *differ) env(field, path string, old, new []corev1.EnvVar) {
+	oldIndex := make(map[string]int, len(old))
+	for i, e := range old {
+		oldIndex[e.Name] = i
+	}
+
+	for _, n := range new {
+		name := fmt.Sprintf("%s[%s]", field, n.Name)
+		i, ok := oldIndex[n.Name]
+		if !ok {
+			d.change(Change{Field: name, Kind: Added, New: envValue(n)})
+			d.ensure(path, len(old) > 0, []corev1.EnvVar{})
+			d.adds = append(d.adds, Operation{Op: "add", Path: path + "/-", Value: n})
+			continue
+		}
+		delete(oldIndex, n.Name)
+
+		if !equality.Semantic.DeepEqual(old[i], n) {
+			d.change(Change{Field: name, Kind: Changed, Old: envValue(old[i]), New: envValue(n)})
+			d.replaces = append(d.replaces, Operation{Op: "replace", Path: path + "/" + strconv.Itoa(i), Value: n})
+		}
+	}
+
+	for _, name := range leftover(oldIndex) {
+		i := oldIndex[name]
+		d.change(Change{Field: fmt.Sprintf("%s[%s]", field, name), Kind: Removed, Old: envValue(old[i])})
+		d.removes = append(d.removes, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+}
+
+func (d *differ) resources(field, path, list string, old, new corev1.ResourceList) {
+	oldValues := make(map[string]string, len(old))
+	for name, q := range old {
+		oldValues[string(name)] = q.String()
+	}
+	newValues := make(map[string]string, len(new))
+	for name, q := range new {
+		newValues[string(name)] = q.String()
+		// Quantities are compared by value, so 1000m equals 1
+		if o, ok := old[name]; ok && o.Cmp(q) == 0 {
+			oldValues[string(name)] = newValues[string(name)]
+		}
+	}
+	d.stringMap(field, path+"/"+list, oldValues, newValues, nil)
+}
+
+func (d *differ) stringMap(field, path string, old, new map[string]string, ignored map[string]bool) {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if ignored[k] {
+			continue
+		}
+		o, inOld := old[k]
+		n, inNew := new[k]
+		name := fmt.Sprintf("%s[%s]", field, k)
+		at := path + "/" + escape(k)
+		switch {
+		case !inOld:
+			d.change(Change{Field: name, Kind: Added, New: n})
+			d.ensure(path, len(old) > 0, map[string]string{})
+			d.adds = append(d.adds, Operation{Op: "add", Path: at, Value: n})
+		case !inNew:
+			d.change(Change{Field: name, Kind: Removed, Old: o})
+			d.removes = append(d.removes, Operation{Op: "remove", Path: at})
+		case o != n:
+			d.change(Change{Field: name, Kind: Changed, Old: o, New: n})
+			d.replaces = append(d.replaces, Operation{Op: "replace", Path: at, Value: n})
+		}
+	}
+}
+
+// conditions compares conditions by type on status and reason; messages and
+// timestamps change too often to be worth reporting
+func (d *differ) conditions(old, new []appsv1.DeploymentCondition) {
+	path := "/status/conditions"
+	oldIndex := make(map[appsv1.DeploymentConditionType]int, len(old))
+	for i, c := range old {
+		oldIndex[c.Type] = i
+	}
+
+	for _, n := range new {
+		name := fmt.Sprintf("conditions[%s]", n.Type)
+		i, ok := oldIndex[n.Type]
+		if !ok {
+			d.change(Change{Field: name, Kind: Added, New: conditionValue(n), Status: true})
+			d.ensure(path, len(old) > 0, []appsv1.DeploymentCondition{})
+			d.adds = append(d.adds, Operation{Op: "add", Path: path + "/-", Value: n})
+			continue
+		}
+		delete(oldIndex, n.Type)
+
+		o := old[i]
+		if o.Status != n.Status || o.Reason != n.Reason {
+			d.change(Change{Field: name, Kind: Changed, Old: conditionValue(o), New: conditionValue(n), Status: true})
+			d.replaces = append(d.replaces, Operation{Op: "replace", Path: path + "/" + strconv.Itoa(i), Value: n})
+		}
+	}
+
+	for _, t := range leftover(oldIndex) {
+		i := oldIndex[t]
+		d.change(Change{Field: fmt.Sprintf("conditions[%s]", t), Kind: Removed, Old: conditionValue(old[i]), Status: true})
+		d.removes = append(d.removes, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+}
+
+// replicas returns the replica count of a spec; nil means the default, 1
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+// envValue shows an env var without dumping its source object
+func envValue(e corev1.EnvVar) string {
+	from := e.ValueFrom
+	switch {
+	case from == nil:
+		return e.Value
+	case from.SecretKeyRef != nil:
+		return fmt.Sprintf("secret %s/%s", from.SecretKeyRef.Name, from.SecretKeyRef.Key)
+	case from.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("configmap %s/%s", from.ConfigMapKeyRef.Name, from.ConfigMapKeyRef.Key)
+	case from.FieldRef != nil:
+		return "field " + from.FieldRef.FieldPath
+	case from.ResourceFieldRef != nil:
+		return "resource " + from.ResourceFieldRef.Resource
+	}
+	return ""
+}
+
+func conditionValue(c appsv1.DeploymentCondition) string {
+	if c.Reason == "" {
+		return string(c.Status)
+	}
+	return fmt.Sprintf("%s (%s)", c.Status, c.Reason)
+}
+
+// escape encodes a key as a JSON Pointer token
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// pathAfter orders JSON Pointers so that later list positions and deeper
+// paths come first
+func pathAfter(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an > bn
+		}
+		return as[i] > bs[i]
+	}
+	return len(as) > len(bs)
+}
+
+// leftover returns the positions still in index, ordered by name
+func leftover[K ~string](index map[K]int) []K {
+	names := make([]K, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
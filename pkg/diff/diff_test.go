@@ -0,0 +1,274 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testDeployment returns a deployment with two containers and a status
+func testDeployment() *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "shop",
+			Name:        "web",
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "web",
+							Image: "web:1",
+							Env: []corev1.EnvVar{
+								{Name: "A", Value: "1"},
+								{Name: "B", Value: "2"},
+								{Name: "C", Value: "3"},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+							},
+						},
+						{Name: "proxy", Image: "proxy:1", Env: []corev1.EnvVar{{Name: "UPSTREAM", Value: "web"}}},
+						{Name: "metrics", Image: "metrics:1"},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:      2,
+			ReadyReplicas: 2,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"},
+				{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+			},
+		},
+	}
+}
+
+// apply applies the patch of result to old and returns the patched copy
+func apply(t *testing.T, old *appsv1.Deployment, result Result) *appsv1.Deployment {
+	t.Helper()
+	doc, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := json.Marshal(result.Patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := jsonpatch.DecodePatch(ops)
+	if err != nil {
+		t.Fatalf("invalid patch %s: %v", ops, err)
+	}
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		t.Fatalf("failed to apply %s: %v", ops, err)
+	}
+	var d appsv1.Deployment
+	if err := json.Unmarshal(patched, &d); err != nil {
+		t.Fatal(err)
+	}
+	return &d
+}
+
+func TestPatchTurnsOldIntoNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		old    func(d *appsv1.Deployment)
+		change func(d *appsv1.Deployment)
+	}{
+		{
+			name: "scale to zero",
+			change: func(d *appsv1.Deployment) {
+				zero := int32(0)
+				d.Spec.Replicas = &zero
+			},
+		},
+		{
+			name: "containers added and removed with env changes",
+			change: func(d *appsv1.Deployment) {
+				web := d.Spec.Template.Spec.Containers[0]
+				web.Image = "web:2"
+				web.Env = []corev1.EnvVar{
+					{Name: "B", Value: "two"},
+					{Name: "D", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "db"}, Key: "password"}}},
+				}
+				metrics := d.Spec.Template.Spec.Containers[2]
+				metrics.Env = []corev1.EnvVar{{Name: "PORT", Value: "9090"}}
+				d.Spec.Template.Spec.Containers = []corev1.Container{
+					web,
+					metrics,
+					{Name: "sidecar", Image: "sidecar:1", Env: []corev1.EnvVar{{Name: "X", Value: ""}}},
+					{Name: "logs", Image: "logs:1"},
+				}
+			},
+		},
+		{
+			name: "every container replaced",
+			change: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "new", Image: "new:1"}}
+			},
+		},
+		{
+			name: "first init container",
+			change: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "migrate", Image: "migrate:1"}}
+			},
+		},
+		{
+			name: "resources",
+			change: func(d *appsv1.Deployment) {
+				c := &d.Spec.Template.Spec.Containers[0]
+				c.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+				c.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			},
+		},
+		{
+			name: "first label",
+			old:  func(d *appsv1.Deployment) { d.Labels = nil },
+			change: func(d *appsv1.Deployment) {
+				d.Labels = map[string]string{"app": "web"}
+			},
+		},
+		{
+			name: "first annotation, empty value",
+			old:  func(d *appsv1.Deployment) { d.Annotations = nil },
+			change: func(d *appsv1.Deployment) {
+				d.Annotations = map[string]string{"example.com/reviewed": ""}
+			},
+		},
+		{
+			name: "keys with / and ~",
+			old: func(d *appsv1.Deployment) {
+				d.Labels["app.kubernetes.io/name"] = "web"
+				d.Labels["team~old"] = "a"
+			},
+			change: func(d *appsv1.Deployment) {
+				d.Labels = map[string]string{
+					"app.kubernetes.io/name":    "shop-web",
+					"app.kubernetes.io/part-of": "shop",
+					"owner~team":                "payments",
+				}
+			},
+		},
+		{
+			name: "status and conditions",
+			change: func(d *appsv1.Deployment) {
+				d.Status.ReadyReplicas = 0
+				d.Status.UnavailableReplicas = 2
+				d.Status.Conditions = []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+					{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate"},
+				}
+			},
+		},
+		{
+			name: "first condition",
+			old:  func(d *appsv1.Deployment) { d.Status.Conditions = nil },
+			change: func(d *appsv1.Deployment) {
+				d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := testDeployment()
+			if tt.old != nil {
+				tt.old(old)
+			}
+			new := old.DeepCopy()
+			tt.change(new)
+
+			result := Deployments(old, new, Options{})
+			if result.Empty() {
+				t.Fatal("no changes found")
+			}
+			patched := apply(t, old, result)
+
+			if rest := Deployments(patched, new, Options{}); !rest.Empty() {
+				t.Errorf("patched deployment still differs: %+v\npatch: %+v", rest.Changes, result.Patch)
+			}
+			if !equality.Semantic.DeepEqual(patched.Spec, new.Spec) {
+				t.Errorf("spec after patch = %+v, want %+v", patched.Spec, new.Spec)
+			}
+			if !equality.Semantic.DeepEqual(patched.Status, new.Status) {
+				t.Errorf("status after patch = %+v, want %+v", patched.Status, new.Status)
+			}
+			if len(patched.Labels)+len(new.Labels) > 0 && !equality.Semantic.DeepEqual(patched.Labels, new.Labels) {
+				t.Errorf("labels after patch = %v, want %v", patched.Labels, new.Labels)
+			}
+		})
+	}
+}
+
+func TestPatchIgnoreStatus(t *testing.T) {
+	old := testDeployment()
+	new := old.DeepCopy()
+	new.Spec.Template.Spec.Containers[0].Image = "web:2"
+	new.Status.ReadyReplicas = 1
+	new.Status.Conditions = new.Status.Conditions[:1]
+
+	result := Deployments(old, new, Options{IgnoreStatus: true})
+	for _, c := range result.Changes {
+		if c.Status {
+			t.Errorf("status change reported: %+v", c)
+		}
+	}
+	patched := apply(t, old, result)
+	if patched.Spec.Template.Spec.Containers[0].Image != "web:2" {
+		t.Errorf("image not patched")
+	}
+	if !equality.Semantic.DeepEqual(patched.Status, old.Status) {
+		t.Errorf("status patched: %+v", patched.Status)
+	}
+
+	if result := Deployments(old, old.DeepCopy(), Options{}); !result.Empty() || len(result.Patch) != 0 {
+		t.Errorf("unchanged deployment: %+v", result)
+	}
+	statusOnly := old.DeepCopy()
+	statusOnly.Status.ReadyReplicas = 1
+	if result := Deployments(old, statusOnly, Options{IgnoreStatus: true}); !result.Empty() {
+		t.Errorf("status-only change with IgnoreStatus: %+v", result.Changes)
+	}
+}
+
+func TestIgnoredChanges(t *testing.T) {
+	old := testDeployment()
+	new := old.DeepCopy()
+	new.ResourceVersion = "2"
+	new.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"spec":{}}`
+	new.Status.Conditions[0].LastUpdateTime = metav1.Now()
+	new.Status.Conditions[0].Message = "Deployment has minimum availability."
+	// 1000m equals 1
+	old.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("1")
+	new.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("1000m")
+
+	if result := Deployments(old, new, Options{}); !result.Empty() {
+		t.Errorf("bookkeeping reported as changes: %+v", result.Changes)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for key, want := range map[string]string{
+		"app":                    "app",
+		"app.kubernetes.io/name": "app.kubernetes.io~1name",
+		"a~b":                    "a~0b",
+		"~/":                     "~0~1",
+	} {
+		if got := escape(key); got != want {
+			t.Errorf("escape(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ANSI escape codes used by Fprint
+const (
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	dim    = "\x1b[2m"
+	reset  = "\x1b[0m"
+)
+
+// Fprint writes one line per change, indented under the event line:
+//
+//	[12:00:00] MODIFIED: web
+//	    + labels[tier]: web
+//	    - containers[web].env[DEBUG]: true
+//	    ~ containers[web].image: nginx:1.27 -> nginx:1.28
+//
+// With color, additions are green, removals red, changes yellow and status
+// changes dimmed.
+func Fprint(w io.Writer, changes []Change, color bool) {
+	for _, c := range changes {
+		var sign, code, line string
+		switch c.Kind {
+		case Added:
+			sign, code, line = "+", green, fmt.Sprintf("%s: %v", c.Field, c.New)
+		case Removed:
+			sign, code, line = "-", red, fmt.Sprintf("%s: %v", c.Field, c.Old)
+		default:
+			sign, code, line = "~", yellow, fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+		}
+		if c.Status {
+			code = dim
+		}
+
+		if color {
+			fmt.Fprintf(w, "    %s%s %s%s\n", code, sign, line, reset)
+		} else {
+			fmt.Fprintf(w, "    %s %s\n", sign, line)
+		}
+	}
+}
+
+// ColorEnabled reports whether f is a terminal and NO_COLOR (no-color.org)
+// is empty
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+
+	monitoringv1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	scheme "github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// DeploymentMonitorsGetter has a method to return a DeploymentMonitorInterface.
+// A group's client should implement this interface.
+type DeploymentMonitorsGetter interface {
+	DeploymentMonitors(namespace string) DeploymentMonitorInterface
+}
+
+// DeploymentMonitorInterface has methods to work with DeploymentMonitor resources.
+type DeploymentMonitorInterface interface {
+	Create(ctx context.Context, deploymentMonitor *monitoringv1alpha1.DeploymentMonitor, opts v1.CreateOptions) (*monitoringv1alpha1.DeploymentMonitor, error)
+	Update(ctx context.Context, deploymentMonitor *monitoringv1alpha1.DeploymentMonitor, opts v1.UpdateOptions) (*monitoringv1alpha1.DeploymentMonitor, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, deploymentMonitor *monitoringv1alpha1.DeploymentMonitor, opts v1.UpdateOptions) (*monitoringv1alpha1.DeploymentMonitor, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*monitoringv1alpha1.DeploymentMonitor, error)
+	List(ctx context.Context, opts v1.ListOptions) (*monitoringv1alpha1.DeploymentMonitorList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *monitoringv1alpha1.DeploymentMonitor, err error)
+	DeploymentMonitorExpansion
+}
+
+// deploymentMonitors implements DeploymentMonitorInterface
+type deploymentMonitors struct {
+	*gentype.ClientWithList[*monitoringv1alpha1.DeploymentMonitor, *monitoringv1alpha1.DeploymentMonitorList]
+}
+
+// newDeploymentMonitors returns a DeploymentMonitors
+func newDeploymentMonitors(c *MonitoringV1alpha1Client, namespace string) *deploymentMonitors {
+	return &deploymentMonitors{
+		gentype.NewClientWithList[*monitoringv1alpha1.DeploymentMonitor, *monitoringv1alpha1.DeploymentMonitorList](
+			"deploymentmonitors",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *monitoringv1alpha1.DeploymentMonitor { return &monitoringv1alpha1.DeploymentMonitor{} },
+			func() *monitoringv1alpha1.DeploymentMonitorList { return &monitoringv1alpha1.DeploymentMonitorList{} },
+		),
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	monitoringv1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned/typed/monitoring/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeDeploymentMonitors implements DeploymentMonitorInterface
+type fakeDeploymentMonitors struct {
+	*gentype.FakeClientWithList[*v1alpha1.DeploymentMonitor, *v1alpha1.DeploymentMonitorList]
+	Fake *FakeMonitoringV1alpha1
+}
+
+func newFakeDeploymentMonitors(fake *FakeMonitoringV1alpha1, namespace string) monitoringv1alpha1.DeploymentMonitorInterface {
+	return &fakeDeploymentMonitors{
+		gentype.NewFakeClientWithList[*v1alpha1.DeploymentMonitor, *v1alpha1.DeploymentMonitorList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("deploymentmonitors"),
+			v1alpha1.SchemeGroupVersion.WithKind("DeploymentMonitor"),
+			func() *v1alpha1.DeploymentMonitor { return &v1alpha1.DeploymentMonitor{} },
+			func() *v1alpha1.DeploymentMonitorList { return &v1alpha1.DeploymentMonitorList{} },
+			func(dst, src *v1alpha1.DeploymentMonitorList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.DeploymentMonitorList) []*v1alpha1.DeploymentMonitor {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.DeploymentMonitorList, items []*v1alpha1.DeploymentMonitor) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -0,0 +1,28 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned/typed/monitoring/v1alpha1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeMonitoringV1alpha1 struct {
+	*testing.Fake
+}
+
+func (c *FakeMonitoringV1alpha1) DeploymentMonitors(namespace string) v1alpha1.DeploymentMonitorInterface {
+	return newFakeDeploymentMonitors(c, namespace)
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeMonitoringV1alpha1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+	time "time"
+
+	apismonitoringv1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	versioned "github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/yourusername/k8s-controller-tutorial/pkg/generated/informers/externalversions/internalinterfaces"
+	monitoringv1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/generated/listers/monitoring/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DeploymentMonitorInformer provides access to a shared informer and lister for
+// DeploymentMonitors.
+type DeploymentMonitorInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() monitoringv1alpha1.DeploymentMonitorLister
+}
+
+type deploymentMonitorInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewDeploymentMonitorInformer constructs a new informer for DeploymentMonitor type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewDeploymentMonitorInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredDeploymentMonitorInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredDeploymentMonitorInformer constructs a new informer for DeploymentMonitor type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredDeploymentMonitorInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MonitoringV1alpha1().DeploymentMonitors(namespace).List(context.Background(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MonitoringV1alpha1().DeploymentMonitors(namespace).Watch(context.Background(), options)
+			},
+			ListWithContextFunc: func(ctx context.Context, options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MonitoringV1alpha1().DeploymentMonitors(namespace).List(ctx, options)
+			},
+			WatchFuncWithContext: func(ctx context.Context, options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MonitoringV1alpha1().DeploymentMonitors(namespace).Watch(ctx, options)
+			},
+		},
+		&apismonitoringv1alpha1.DeploymentMonitor{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *deploymentMonitorInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredDeploymentMonitorInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *deploymentMonitorInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apismonitoringv1alpha1.DeploymentMonitor{}, f.defaultInformer)
+}
+
+func (f *deploymentMonitorInformer) Lister() monitoringv1alpha1.DeploymentMonitorLister {
+	return monitoringv1alpha1.NewDeploymentMonitorLister(f.Informer().GetIndexer())
+}
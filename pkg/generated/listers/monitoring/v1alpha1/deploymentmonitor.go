@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	monitoringv1alpha1 "github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DeploymentMonitorLister helps list DeploymentMonitors.
+// All objects returned here must be treated as read-only.
+type DeploymentMonitorLister interface {
+	// List lists all DeploymentMonitors in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*monitoringv1alpha1.DeploymentMonitor, err error)
+	// DeploymentMonitors returns an object that can list and get DeploymentMonitors.
+	DeploymentMonitors(namespace string) DeploymentMonitorNamespaceLister
+	DeploymentMonitorListerExpansion
+}
+
+// deploymentMonitorLister implements the DeploymentMonitorLister interface.
+type deploymentMonitorLister struct {
+	listers.ResourceIndexer[*monitoringv1alpha1.DeploymentMonitor]
+}
+
+// NewDeploymentMonitorLister returns a new DeploymentMonitorLister.
+func NewDeploymentMonitorLister(indexer cache.Indexer) DeploymentMonitorLister {
+	return &deploymentMonitorLister{listers.New[*monitoringv1alpha1.DeploymentMonitor](indexer, monitoringv1alpha1.Resource("deploymentmonitor"))}
+}
+
+// DeploymentMonitors returns an object that can list and get DeploymentMonitors.
+func (s *deploymentMonitorLister) DeploymentMonitors(namespace string) DeploymentMonitorNamespaceLister {
+	return deploymentMonitorNamespaceLister{listers.NewNamespaced[*monitoringv1alpha1.DeploymentMonitor](s.ResourceIndexer, namespace)}
+}
+
+// DeploymentMonitorNamespaceLister helps list and get DeploymentMonitors.
+// All objects returned here must be treated as read-only.
+type DeploymentMonitorNamespaceLister interface {
+	// List lists all DeploymentMonitors in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*monitoringv1alpha1.DeploymentMonitor, err error)
+	// Get retrieves the DeploymentMonitor from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*monitoringv1alpha1.DeploymentMonitor, error)
+	DeploymentMonitorNamespaceListerExpansion
+}
+
+// deploymentMonitorNamespaceLister implements the DeploymentMonitorNamespaceLister
+// interface.
+type deploymentMonitorNamespaceLister struct {
+	listers.ResourceIndexer[*monitoringv1alpha1.DeploymentMonitor]
+}
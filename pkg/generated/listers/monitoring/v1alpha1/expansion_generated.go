@@ -0,0 +1,15 @@
+/*
+Copyright © 2025 Michael Vaynagiy (Michaelcode2)
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// DeploymentMonitorListerExpansion allows custom methods to be added to
+// DeploymentMonitorLister.
+type DeploymentMonitorListerExpansion interface{}
+
+// DeploymentMonitorNamespaceListerExpansion allows custom methods to be added to
+// DeploymentMonitorNamespaceLister.
+type DeploymentMonitorNamespaceListerExpansion interface{}
@@ -0,0 +1,245 @@
+package healthz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Checker is one named health check
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type namedCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c *namedCheck) Name() string                    { return c.name }
+func (c *namedCheck) Check(ctx context.Context) error { return c.check(ctx) }
+
+// NamedCheck turns a function into a Checker
+func NamedCheck(name string, check func(ctx context.Context) error) Checker {
+	return &namedCheck{name: name, check: check}
+}
+
+// Ping always passes; it shows that the process answers requests
+var Ping = NamedCheck("ping", func(context.Context) error { return nil })
+
+// Once passes forever after c passed once. Startup probes use it: a process
+// that has started does not stop having started.
+func Once(c Checker) Checker {
+	var (
+		mu     sync.Mutex
+		passed bool
+	)
+	return NamedCheck(c.Name(), func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if passed {
+			return nil
+		}
+		if err := c.Check(ctx); err != nil {
+			return err
+		}
+		passed = true
+		return nil
+	})
+}
+
+// Freshness fails when last reports a time older than maxAge, or the zero
+// time
+func Freshness(name string, maxAge time.Duration, last func() time.Time) Checker {
+	return NamedCheck(name, func(context.Context) error {
+		t := last()
+		if t.IsZero() {
+			return fmt.Errorf("no activity yet")
+		}
+		if age := time.Since(t); age > maxAge {
+			return fmt.Errorf("last activity %s ago (limit %s)", age.Round(time.Second), maxAge)
+		}
+		return nil
+	})
+}
+
+// InformerSync is the informer-sync check: it fails until every informer
+// added to it has listed its objects. Without informers it passes.
+type InformerSync struct {
+	mu     sync.RWMutex
+	names  []string
+	synced map[string]func() bool
+}
+
+// NewInformerSync creates the check without informers
+func NewInformerSync() *InformerSync {
+	return &InformerSync{synced: make(map[string]func() bool)}
+}
+
+// Add adds the HasSynced function of an informer, named after its resource
+func (s *InformerSync) Add(name string, hasSynced func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.synced[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.synced[name] = hasSynced
+}
+
+func (s *InformerSync) Name() string { return "informer-sync" }
+
+func (s *InformerSync) Check(context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var pending []string
+	for _, name := range s.names {
+		if !s.synced[name]() {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("not synced: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Set is the group of checks behind one probe endpoint such as /readyz.
+// Checks can be added while it is served.
+type Set struct {
+	name string
+
+	mu     sync.RWMutex
+	checks []Checker
+
+	// Failed, if set, is called for every failed check, e.g. to log it. The
+	// reason is only shown in responses with ?verbose.
+	Failed func(name string, err error)
+}
+
+// NewSet creates a set; name is used in the response, e.g. "readyz check
+// passed"
+func NewSet(name string, checks ...Checker) *Set {
+	return &Set{name: name, checks: checks}
+}
+
+// Add appends checks to the set
+func (s *Set) Add(checks ...Checker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, checks...)
+}
+
+// result is the outcome of one check
+type result struct {
+	name     string
+	err      error
+	excluded bool
+}
+
+// Handler runs the checks concurrently, each with timeout, and answers 200
+// when all pass and 500 otherwise, as the Kubernetes API server does:
+//
+//	GET /readyz                      ok
+//	GET /readyz?verbose              [+]ping ok, [-]apiserver failed: <reason>, ...
+//	GET /readyz?exclude=apiserver    skips the apiserver check
+//
+// exclude can be repeated or hold a comma-separated list.
+func (s *Set) Handler(timeout time.Duration) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		args := ctx.QueryArgs()
+		verbose := args.Has("verbose")
+		excluded := make(map[string]bool)
+		for _, value := range args.PeekMulti("exclude") {
+			for _, name := range strings.Split(string(value), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					excluded[name] = true
+				}
+			}
+		}
+
+		s.mu.RLock()
+		checks := append([]Checker(nil), s.checks...)
+		s.mu.RUnlock()
+
+		results := s.run(checks, excluded, timeout)
+
+		failed := false
+		var body strings.Builder
+		for _, r := range results {
+			delete(excluded, r.name)
+			switch {
+			case r.excluded:
+				fmt.Fprintf(&body, "[+]%s excluded: ok\n", r.name)
+			case r.err != nil:
+				failed = true
+				reason := "reason withheld"
+				if verbose {
+					reason = r.err.Error()
+				}
+				fmt.Fprintf(&body, "[-]%s failed: %s\n", r.name, reason)
+			default:
+				fmt.Fprintf(&body, "[+]%s ok\n", r.name)
+			}
+		}
+		if len(excluded) > 0 {
+			unknown := make([]string, 0, len(excluded))
+			for name := range excluded {
+				unknown = append(unknown, fmt.Sprintf("%q", name))
+			}
+			sort.Strings(unknown)
+			fmt.Fprintf(&body, "warn: some health checks cannot be excluded: no matches for %s\n", strings.Join(unknown, ","))
+		}
+
+		ctx.SetContentType("text/plain; charset=utf-8")
+		ctx.Response.Header.Set("X-Content-Type-Options", "nosniff")
+		switch {
+		case failed:
+			fmt.Fprintf(&body, "%s check failed\n", s.name)
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			ctx.SetBodyString(body.String())
+		case verbose:
+			fmt.Fprintf(&body, "%s check passed\n", s.name)
+			ctx.SetBodyString(body.String())
+		default:
+			ctx.SetBodyString("ok")
+		}
+	}
+}
+
+// run runs the checks that are not excluded concurrently and returns the
+// results in the order of checks
+func (s *Set) run(checks []Checker, excluded map[string]bool, timeout time.Duration) []result {
+	results := make([]result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		results[i].name = c.Name()
+		if excluded[c.Name()] {
+			results[i].excluded = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *result, c Checker) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			r.err = c.Check(ctx)
+		}(&results[i], c)
+	}
+	wg.Wait()
+
+	if s.Failed != nil {
+		for _, r := range results {
+			if r.err != nil {
+				s.Failed(r.name, r.err)
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,70 @@
+package healthz
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// probe sends GET uri to the handler of s
+func probe(s *Set, uri string) (int, string) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(uri)
+	s.Handler(time.Second)(ctx)
+	return ctx.Response.StatusCode(), string(ctx.Response.Body())
+}
+
+func TestInformerSync(t *testing.T) {
+	informers := NewInformerSync()
+	if err := informers.Check(context.Background()); err != nil {
+		t.Fatalf("check without informers failed: %v", err)
+	}
+
+	var monitors, pods atomic.Bool
+	informers.Add("deploymentmonitors", monitors.Load)
+	informers.Add("pods", pods.Load)
+
+	monitors.Store(true)
+	err := informers.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "pods") || strings.Contains(err.Error(), "deploymentmonitors") {
+		t.Fatalf("Check() = %v, want only pods pending", err)
+	}
+
+	pods.Store(true)
+	if err := informers.Check(context.Background()); err != nil {
+		t.Fatalf("Check() = %v after every informer synced", err)
+	}
+}
+
+func TestSetHandler(t *testing.T) {
+	var synced atomic.Bool
+	informers := NewInformerSync()
+	informers.Add("deploymentmonitors", synced.Load)
+	s := NewSet("readyz", Ping, informers)
+
+	code, body := probe(s, "/readyz")
+	if code != fasthttp.StatusInternalServerError || !strings.Contains(body, "[-]informer-sync failed: reason withheld") {
+		t.Errorf("unsynced: %d %q", code, body)
+	}
+	code, body = probe(s, "/readyz?verbose")
+	if code != fasthttp.StatusInternalServerError || !strings.Contains(body, "not synced: deploymentmonitors") {
+		t.Errorf("unsynced, verbose: %d %q", code, body)
+	}
+	code, body = probe(s, "/readyz?exclude=informer-sync&exclude=leader")
+	if code != fasthttp.StatusOK || body != "ok" {
+		t.Errorf("excluded: %d %q", code, body)
+	}
+	code, body = probe(s, "/readyz?verbose&exclude=informer-sync,leader")
+	if !strings.Contains(body, "[+]informer-sync excluded: ok") || !strings.Contains(body, `no matches for "leader"`) {
+		t.Errorf("excluded, verbose: %d %q", code, body)
+	}
+
+	synced.Store(true)
+	if code, body = probe(s, "/readyz"); code != fasthttp.StatusOK || body != "ok" {
+		t.Errorf("synced: %d %q", code, body)
+	}
+}
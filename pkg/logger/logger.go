@@ -1,16 +1,35 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger wraps zerolog.Logger for easier usage
 type Logger struct {
-	logger zerolog.Logger
+	logger   zerolog.Logger
+	scope    string
+	sampler  *sampler
+	redactor *redactor
+}
+
+// Levels are the log levels accepted by SetLevel, from most to least verbose
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// DefaultLevel is info in production and debug otherwise
+func DefaultLevel() string {
+	switch os.Getenv("ENV") {
+	case "prod", "production":
+		return "info"
+	}
+	return "debug"
 }
 
 // New creates a new logger instance based on environment
@@ -21,16 +40,14 @@ func New() *Logger {
 		env = "dev" // default to dev environment
 	}
 
-	var level zerolog.Level
+	level, _ := zerolog.ParseLevel(DefaultLevel())
 	switch env {
 	case "prod", "production":
-		level = zerolog.InfoLevel
 		// In production, use JSON format for better parsing
 		zerolog.TimeFieldFormat = time.RFC3339
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
 	default:
 		// In development, use pretty console output
-		level = zerolog.DebugLevel
 		zerolog.TimeFieldFormat = "15:04:05"
 		log.Logger = log.Output(zerolog.ConsoleWriter{
 			Out:        os.Stdout,
@@ -65,75 +82,145 @@ func New() *Logger {
 		Str("environment", env).
 		Logger()
 
-	return &Logger{logger: logger}
+	return &Logger{
+		logger:   logger,
+		sampler:  newSampler(),
+		redactor: newRedactor(DefaultRedactionConfig()),
+	}
+}
+
+// SetLevel changes the minimum level of all loggers
+func (l *Logger) SetLevel(level string) error {
+	for _, name := range Levels {
+		if name == level {
+			parsed, _ := zerolog.ParseLevel(level)
+			zerolog.SetGlobalLevel(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown log level %q (expected one of %s)", level, strings.Join(Levels, ", "))
 }
 
-// Debug logs a debug message
-func (l *Logger) Debug(msg string, fields map[string]interface{}) {
-	event := l.logger.Debug()
+// SetRedaction replaces the redaction rules of this logger and every logger derived from it
+func (l *Logger) SetRedaction(cfg RedactionConfig) error {
+	return l.redactor.configure(cfg)
+}
+
+// Redact masks sensitive data in a string using the logger's redaction rules
+func (l *Logger) Redact(s string) string {
+	return l.redactor.text(s)
+}
+
+// SetSampling applies sampling rules to this logger and every logger derived from it
+func (l *Logger) SetSampling(cfg SamplingConfig) error {
+	return l.sampler.configure(cfg, l.logger)
+}
+
+// SamplingStats returns the number of messages suppressed by sampling so far
+func (l *Logger) SamplingStats() SamplingStats {
+	return l.sampler.snapshot()
+}
+
+// Close flushes pending "repeated N times" summaries and stops background work
+func (l *Logger) Close() {
+	l.sampler.close()
+}
+
+// write sends a message through the sampler and writes it at the given level
+func (l *Logger) write(level zerolog.Level, msg string, err error, fields map[string]interface{}) {
+	if level < zerolog.GlobalLevel() || level < l.logger.GetLevel() {
+		return
+	}
+
+	msg = l.redactor.text(msg)
+	fields = l.redactor.fields(fields)
+	if !l.sampler.allow(l.logger, l.scope, level, msg, fields) {
+		return
+	}
+
+	event := l.logger.WithLevel(level)
+	if err != nil {
+		event = event.Str(zerolog.ErrorFieldName, l.redactor.text(err.Error()))
+	}
 	for k, v := range fields {
 		event = event.Interface(k, v)
 	}
 	event.Msg(msg)
 }
 
+// Debug logs a debug message
+func (l *Logger) Debug(msg string, fields map[string]interface{}) {
+	l.write(zerolog.DebugLevel, msg, nil, fields)
+}
+
 // Info logs an info message
 func (l *Logger) Info(msg string, fields map[string]interface{}) {
-	event := l.logger.Info()
-	for k, v := range fields {
-		event = event.Interface(k, v)
-	}
-	event.Msg(msg)
+	l.write(zerolog.InfoLevel, msg, nil, fields)
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(msg string, fields map[string]interface{}) {
-	event := l.logger.Warn()
-	for k, v := range fields {
-		event = event.Interface(k, v)
-	}
-	event.Msg(msg)
+	l.write(zerolog.WarnLevel, msg, nil, fields)
 }
 
 // Error logs an error message
 func (l *Logger) Error(msg string, err error, fields map[string]interface{}) {
-	event := l.logger.Error()
-	if err != nil {
-		event = event.Err(err)
-	}
-	for k, v := range fields {
-		event = event.Interface(k, v)
-	}
-	event.Msg(msg)
+	l.write(zerolog.ErrorLevel, msg, err, fields)
 }
 
 // Fatal logs a fatal message and exits
 func (l *Logger) Fatal(msg string, err error, fields map[string]interface{}) {
 	event := l.logger.Fatal()
 	if err != nil {
-		event = event.Err(err)
+		event = event.Str(zerolog.ErrorFieldName, l.redactor.text(err.Error()))
 	}
-	for k, v := range fields {
+	for k, v := range l.redactor.fields(fields) {
 		event = event.Interface(k, v)
 	}
-	event.Msg(msg)
+	l.sampler.close()
+	event.Msg(l.redactor.text(msg))
 }
 
 // WithNamespace returns a logger with namespace field
 func (l *Logger) WithNamespace(namespace string) *Logger {
 	return &Logger{
-		logger: l.logger.With().Str("namespace", namespace).Logger(),
+		logger:   l.logger.With().Str("namespace", namespace).Logger(),
+		scope:    l.scope + "/ns=" + namespace,
+		sampler:  l.sampler,
+		redactor: l.redactor,
 	}
 }
 
 // WithDeployment returns a logger with deployment field
 func (l *Logger) WithDeployment(deploymentName string) *Logger {
 	return &Logger{
-		logger: l.logger.With().Str("deployment", deploymentName).Logger(),
+		logger:   l.logger.With().Str("deployment", deploymentName).Logger(),
+		scope:    l.scope + "/deploy=" + deploymentName,
+		sampler:  l.sampler,
+		redactor: l.redactor,
+	}
+}
+
+// WithContext returns a logger with trace_id and span_id fields from the span in ctx.
+// It returns the logger unchanged when ctx carries no valid span.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return l
+	}
+	return &Logger{
+		logger: l.logger.With().
+			Str("trace_id", spanContext.TraceID().String()).
+			Str("span_id", spanContext.SpanID().String()).
+			Logger(),
+		scope:    l.scope,
+		sampler:  l.sampler,
+		redactor: l.redactor,
 	}
 }
 
-// GetZerologLogger returns the underlying zerolog.Logger for advanced usage
+// GetZerologLogger returns the underlying zerolog.Logger for advanced usage.
+// Messages written through it bypass sampling and redaction.
 func (l *Logger) GetZerologLogger() zerolog.Logger {
 	return l.logger
 }
@@ -0,0 +1,375 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SamplingConfig controls how repetitive log messages are thinned out
+type SamplingConfig struct {
+	// Burst is how many messages with the same text may be logged per Period (0 disables)
+	Burst  int
+	Period time.Duration
+
+	// DedupWindow collapses identical messages logged within the window into a
+	// single "repeated N times" summary (0 disables)
+	DedupWindow time.Duration
+
+	// LevelCaps limits how many messages per second are logged for a level,
+	// keyed by level name ("debug", "info", ...)
+	LevelCaps map[string]int
+
+	// ReportInterval is how often dropped-message counters are logged (0 disables)
+	ReportInterval time.Duration
+}
+
+// Enabled reports whether any sampling rule is active
+func (c SamplingConfig) Enabled() bool {
+	return c.Burst > 0 || c.DedupWindow > 0 || len(c.LevelCaps) > 0
+}
+
+// Validate checks the configuration for invalid values
+func (c SamplingConfig) Validate() error {
+	if c.Burst < 0 {
+		return fmt.Errorf("sampling burst must not be negative: %d", c.Burst)
+	}
+	if c.Burst > 0 && c.Period <= 0 {
+		return fmt.Errorf("sampling period must be positive when burst is set")
+	}
+	if c.DedupWindow < 0 {
+		return fmt.Errorf("dedup window must not be negative: %s", c.DedupWindow)
+	}
+	for name, limit := range c.LevelCaps {
+		if _, err := parseCapLevel(name); err != nil {
+			return err
+		}
+		if limit < 0 {
+			return fmt.Errorf("level cap for %q must not be negative: %d", name, limit)
+		}
+	}
+	return nil
+}
+
+// SamplingStats holds the number of messages suppressed by the sampler
+type SamplingStats struct {
+	DroppedBurst    uint64            `json:"dropped_burst"`
+	DroppedLevelCap map[string]uint64 `json:"dropped_level_cap"`
+	Deduplicated    uint64            `json:"deduplicated"`
+}
+
+// Total returns the total number of suppressed messages
+func (s SamplingStats) Total() uint64 {
+	total := s.DroppedBurst + s.Deduplicated
+	for _, n := range s.DroppedLevelCap {
+		total += n
+	}
+	return total
+}
+
+func parseCapLevel(name string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(strings.ToLower(name))
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level in level cap: %q", name)
+	}
+	if level == zerolog.FatalLevel || level == zerolog.PanicLevel {
+		return zerolog.NoLevel, fmt.Errorf("level %q cannot be capped", name)
+	}
+	return level, nil
+}
+
+// window is a fixed-window counter
+type window struct {
+	start time.Time
+	count int
+}
+
+// hit registers a message in the window and reports whether it is within limit
+func (w *window) hit(now time.Time, period time.Duration, limit int) bool {
+	if now.Sub(w.start) >= period {
+		w.start = now
+		w.count = 0
+	}
+	w.count++
+	return w.count <= limit
+}
+
+// dedupEntry tracks an identical message seen within the dedup window
+type dedupEntry struct {
+	logger   zerolog.Logger
+	level    zerolog.Level
+	msg      string
+	fields   map[string]interface{}
+	first    time.Time
+	repeated int
+}
+
+// sampler decides which messages are written. It is shared by a logger and
+// all loggers derived from it.
+type sampler struct {
+	mu     sync.Mutex
+	config SamplingConfig
+	caps   map[zerolog.Level]int
+
+	bursts  map[string]*window
+	levels  map[zerolog.Level]*window
+	pending map[string]*dedupEntry
+
+	stats      SamplingStats
+	lastReport SamplingStats
+
+	stop chan struct{}
+	// now returns the time a message is logged at
+	now func() time.Time
+}
+
+func newSampler() *sampler {
+	return &sampler{now: time.Now}
+}
+
+// configure replaces the sampling rules and restarts the background flusher
+func (s *sampler) configure(cfg SamplingConfig, report zerolog.Logger) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	caps := make(map[zerolog.Level]int, len(cfg.LevelCaps))
+	for name, limit := range cfg.LevelCaps {
+		level, _ := parseCapLevel(name)
+		caps[level] = limit
+	}
+
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	pending := s.takePending(time.Time{})
+	s.config = cfg
+	s.caps = caps
+	s.bursts = make(map[string]*window)
+	s.levels = make(map[zerolog.Level]*window)
+	s.pending = make(map[string]*dedupEntry)
+	if s.stats.DroppedLevelCap == nil {
+		s.stats.DroppedLevelCap = make(map[string]uint64)
+	}
+	if cfg.Enabled() {
+		s.stop = make(chan struct{})
+		go s.run(s.stop, report)
+	}
+	s.mu.Unlock()
+
+	writeRepeated(pending)
+	return nil
+}
+
+// close stops the background flusher and writes pending summaries
+func (s *sampler) close() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	pending := s.takePending(time.Time{})
+	s.mu.Unlock()
+
+	writeRepeated(pending)
+}
+
+// allow reports whether a message should be written. The summary of an
+// earlier identical message whose dedup window expired is written first,
+// outside the lock, so writers may log themselves.
+func (s *sampler) allow(logger zerolog.Logger, scope string, level zerolog.Level, msg string, fields map[string]interface{}) bool {
+	if s == nil || level >= zerolog.FatalLevel {
+		return true
+	}
+
+	s.mu.Lock()
+	allowed, repeated := s.decide(logger, scope, level, msg, fields)
+	s.mu.Unlock()
+
+	if repeated != nil {
+		writeRepeated([]*dedupEntry{repeated})
+	}
+	return allowed
+}
+
+// decide reports whether a message should be written and returns the entry
+// of an identical message whose summary is due. Callers must hold s.mu.
+func (s *sampler) decide(logger zerolog.Logger, scope string, level zerolog.Level, msg string, fields map[string]interface{}) (bool, *dedupEntry) {
+	if !s.config.Enabled() {
+		return true, nil
+	}
+	now := s.now()
+
+	if limit, ok := s.caps[level]; ok {
+		w := s.levels[level]
+		if w == nil {
+			w = &window{}
+			s.levels[level] = w
+		}
+		if !w.hit(now, time.Second, limit) {
+			s.stats.DroppedLevelCap[level.String()]++
+			return false, nil
+		}
+	}
+
+	if s.config.Burst > 0 {
+		key := scope + "\x00" + level.String() + "\x00" + msg
+		w := s.bursts[key]
+		if w == nil {
+			w = &window{}
+			s.bursts[key] = w
+		}
+		if !w.hit(now, s.config.Period, s.config.Burst) {
+			s.stats.DroppedBurst++
+			return false, nil
+		}
+	}
+
+	var repeated *dedupEntry
+	if s.config.DedupWindow > 0 {
+		key := scope + "\x00" + level.String() + "\x00" + msg + "\x00" + fieldsKey(fields)
+		if entry, ok := s.pending[key]; ok {
+			if now.Sub(entry.first) < s.config.DedupWindow {
+				entry.repeated++
+				s.stats.Deduplicated++
+				return false, nil
+			}
+			if entry.repeated > 0 {
+				repeated = entry
+			}
+		}
+		s.pending[key] = &dedupEntry{
+			logger: logger,
+			level:  level,
+			msg:    msg,
+			fields: fields,
+			first:  now,
+		}
+	}
+
+	return true, repeated
+}
+
+// snapshot returns a snapshot of the dropped-message counters
+func (s *sampler) snapshot() SamplingStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyStats(s.stats)
+}
+
+// run periodically flushes expired dedup entries and reports dropped counters
+func (s *sampler) run(stop chan struct{}, report zerolog.Logger) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	lastReport := s.now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			now := s.now()
+			s.mu.Lock()
+			pending := s.takePending(now)
+			var delta SamplingStats
+			reportDue := s.config.ReportInterval > 0 && now.Sub(lastReport) >= s.config.ReportInterval
+			if reportDue {
+				delta = diffStats(s.stats, s.lastReport)
+				s.lastReport = copyStats(s.stats)
+				lastReport = now
+			}
+			s.mu.Unlock()
+
+			writeRepeated(pending)
+			if reportDue && delta.Total() > 0 {
+				report.Warn().
+					Uint64("dropped_burst", delta.DroppedBurst).
+					Interface("dropped_level_cap", delta.DroppedLevelCap).
+					Uint64("deduplicated", delta.Deduplicated).
+					Msg("Log messages suppressed by sampling")
+			}
+		}
+	}
+}
+
+// takePending removes dedup entries whose window has expired at now, or all
+// entries when now is zero. Callers must hold s.mu.
+func (s *sampler) takePending(now time.Time) []*dedupEntry {
+	var expired []*dedupEntry
+	for key, entry := range s.pending {
+		if now.IsZero() || now.Sub(entry.first) >= s.config.DedupWindow {
+			if entry.repeated > 0 {
+				expired = append(expired, entry)
+			}
+			delete(s.pending, key)
+		}
+	}
+	for key, w := range s.bursts {
+		if !now.IsZero() && now.Sub(w.start) >= s.config.Period {
+			delete(s.bursts, key)
+		}
+	}
+	return expired
+}
+
+// writeRepeated writes a summary line for each collapsed message
+func writeRepeated(entries []*dedupEntry) {
+	for _, entry := range entries {
+		event := entry.logger.WithLevel(entry.level)
+		for k, v := range entry.fields {
+			event = event.Interface(k, v)
+		}
+		event.Int("repeated", entry.repeated).
+			Msgf("%s (repeated %d times)", entry.msg, entry.repeated)
+	}
+}
+
+// fieldsKey builds a stable key from a fields map
+func fieldsKey(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%v;", k, fields[k])
+	}
+	return b.String()
+}
+
+func copyStats(s SamplingStats) SamplingStats {
+	out := SamplingStats{
+		DroppedBurst:    s.DroppedBurst,
+		Deduplicated:    s.Deduplicated,
+		DroppedLevelCap: make(map[string]uint64, len(s.DroppedLevelCap)),
+	}
+	for k, v := range s.DroppedLevelCap {
+		out.DroppedLevelCap[k] = v
+	}
+	return out
+}
+
+func diffStats(current, previous SamplingStats) SamplingStats {
+	out := SamplingStats{
+		DroppedBurst:    current.DroppedBurst - previous.DroppedBurst,
+		Deduplicated:    current.Deduplicated - previous.Deduplicated,
+		DroppedLevelCap: make(map[string]uint64),
+	}
+	for k, v := range current.DroppedLevelCap {
+		if d := v - previous.DroppedLevelCap[k]; d > 0 {
+			out.DroppedLevelCap[k] = d
+		}
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// newSampledLogger returns a logger with cfg applied whose sampler reads the
+// time from the returned clock. Callers must close it.
+func newSampledLogger(t *testing.T, buf *bytes.Buffer, cfg SamplingConfig) (*Logger, *testClock) {
+	t.Helper()
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	l := newTestLogger(buf)
+	clock := &testClock{now: time.Now()}
+	l.sampler.now = clock.Now
+	if err := l.SetSampling(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return l, clock
+}
+
+// testClock is a clock that only moves when advanced
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// messages returns the messages written to buf and clears it
+func messages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		msgs = append(msgs, entry["message"].(string))
+	}
+	buf.Reset()
+	return msgs
+}
+
+func TestLevelCaps(t *testing.T) {
+	var buf bytes.Buffer
+	l, clock := newSampledLogger(t, &buf, SamplingConfig{LevelCaps: map[string]int{"info": 2, "DEBUG": 0}})
+	defer l.Close()
+
+	for i := 0; i < 5; i++ {
+		l.Info("reconciled", nil)
+		l.Debug("noise", nil)
+		l.Warn("slow", nil)
+	}
+	if got := strings.Join(messages(t, &buf), ","); got != "reconciled,slow,reconciled,slow,slow,slow,slow" {
+		t.Errorf("written = %s", got)
+	}
+
+	// Caps apply per second
+	clock.advance(time.Second)
+	l.Info("reconciled", nil)
+	if got := messages(t, &buf); len(got) != 1 {
+		t.Errorf("written after a second = %v", got)
+	}
+
+	stats := l.SamplingStats()
+	if stats.DroppedLevelCap["info"] != 3 || stats.DroppedLevelCap["debug"] != 5 || stats.Total() != 8 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestBurst(t *testing.T) {
+	var buf bytes.Buffer
+	l, clock := newSampledLogger(t, &buf, SamplingConfig{Burst: 2, Period: 10 * time.Second})
+	defer l.Close()
+	shop := l.WithNamespace("shop")
+
+	for i := 0; i < 4; i++ {
+		l.Info("watch restarted", map[string]interface{}{"attempt": i})
+		shop.Info("watch restarted", nil)
+		l.Error("watch restarted", nil, nil)
+	}
+	l.Info("other message", nil)
+	// Per message, level and scope; fields do not matter
+	if got := len(messages(t, &buf)); got != 7 {
+		t.Errorf("wrote %d messages, want 7", got)
+	}
+
+	clock.advance(9 * time.Second)
+	l.Info("watch restarted", nil)
+	clock.advance(time.Second)
+	l.Info("watch restarted", nil)
+	l.Info("watch restarted", nil)
+	if got := len(messages(t, &buf)); got != 2 {
+		t.Errorf("wrote %d messages across the period, want 2", got)
+	}
+	if stats := l.SamplingStats(); stats.DroppedBurst != 7 {
+		t.Errorf("dropped %d messages, want 7", stats.DroppedBurst)
+	}
+}
+
+func TestDedupSummary(t *testing.T) {
+	var buf bytes.Buffer
+	l, clock := newSampledLogger(t, &buf, SamplingConfig{DedupWindow: 5 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		l.Warn("probe failed", map[string]interface{}{"pod": "web-1"})
+		l.Warn("probe failed", map[string]interface{}{"pod": "web-2"})
+		clock.advance(time.Second)
+	}
+	l.Info("probe failed", map[string]interface{}{"pod": "web-1"})
+	if got := strings.Join(messages(t, &buf), ","); got != "probe failed,probe failed,probe failed" {
+		t.Errorf("written within the window = %s", got)
+	}
+
+	// The next message after the window writes the summary first
+	clock.advance(2 * time.Second)
+	l.Warn("probe failed", map[string]interface{}{"pod": "web-1"})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %q, want a summary and the message", lines)
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["message"] != "probe failed (repeated 2 times)" || summary["repeated"] != float64(2) ||
+		summary["pod"] != "web-1" || summary["level"] != "warn" {
+		t.Errorf("summary = %v", summary)
+	}
+	if entry := lastEntry(t, &buf); entry["message"] != "probe failed" || entry["repeated"] != nil {
+		t.Errorf("message after the summary = %v", entry)
+	}
+	buf.Reset()
+
+	// Close writes the summaries still pending
+	l.Close()
+	if got := strings.Join(messages(t, &buf), ","); got != "probe failed (repeated 2 times)" {
+		t.Errorf("written on close = %s", got)
+	}
+	if stats := l.SamplingStats(); stats.Deduplicated != 4 {
+		t.Errorf("deduplicated %d messages, want 4", stats.Deduplicated)
+	}
+}
+
+// statsWriter asks the logger for its stats while writing, as a hook that
+// reports sampling would
+type statsWriter struct {
+	logger *Logger
+	buf    bytes.Buffer
+}
+
+func (w *statsWriter) Write(p []byte) (int, error) {
+	w.logger.SamplingStats()
+	return w.buf.Write(p)
+}
+
+func TestSummaryWrittenWithoutLock(t *testing.T) {
+	var buf bytes.Buffer
+	l, clock := newSampledLogger(t, &buf, SamplingConfig{DedupWindow: time.Second})
+	w := &statsWriter{logger: l}
+	l.logger = zerolog.New(w)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("retrying", nil)
+		l.Info("retrying", nil)
+		clock.advance(time.Second)
+		l.Info("retrying", nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		// Closing would block on the lock as well
+		t.Fatal("writing a dedup summary deadlocked")
+	}
+	l.Close()
+	if got := strings.Count(w.buf.String(), "repeated 1 times"); got != 1 {
+		t.Errorf("wrote %d summaries, want 1: %s", got, w.buf.String())
+	}
+}
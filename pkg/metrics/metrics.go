@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/version"
+)
+
+// Namespace prefixes all metrics of this module
+const Namespace = "k8s_controller"
+
+// Registry holds all metrics exposed at /metrics
+var Registry = prometheus.NewRegistry()
+
+// RequestsRejected counts HTTP requests rejected by rate and concurrency limits
+var RequestsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Subsystem: "http",
+	Name:      "requests_rejected_total",
+	Help:      "HTTP requests rejected by rate limits (reason ip or user) or the in-flight cap (reason in_flight).",
+}, []string{"group", "reason"})
+
+// ConfigReloads counts configuration reloads by result (success or failure)
+var ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "config_reloads_total",
+	Help:      "Configuration reloads triggered by config file changes or SIGHUP, by result (success or failure).",
+}, []string{"result"})
+
+// WatchRestarts counts restarts of Kubernetes watches by watcher, resource and
+// reason
+var WatchRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Subsystem: "watch",
+	Name:      "restarts_total",
+	Help:      "Restarts of Kubernetes watches by watcher (controller, or api for /api/v1/watch streams) and reason: reconnect (resumed after the API server closed the watch), expired (relisted after 410 Gone), error (relisted after a backoff) or target (namespace or selector changed).",
+}, []string{"watcher", "resource", "reason"})
+
+// Alerts is the number of alerts by rule, severity and state (pending or
+// firing)
+var Alerts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: Namespace,
+	Name:      "alerts",
+	Help:      "Alerts of the alerting rules by rule, severity and state (pending or firing).",
+}, []string{"rule", "severity", "state"})
+
+// AlertEvaluations counts evaluations of the alerting rules by result
+// (success or failure)
+var AlertEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "alert_evaluations_total",
+	Help:      "Evaluations of the alerting rules by result (success or failure).",
+}, []string{"result"})
+
+// Notifications counts alert notifications by sink and result (sent, failed
+// for every failed attempt, or dead_lettered)
+var Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "notifications_total",
+	Help:      "Alert notifications by sink and result (sent, failed or dead_lettered).",
+}, []string{"sink", "result"})
+
+// MonitorEvaluations counts evaluations of DeploymentMonitor objects by
+// result (success or failure)
+var MonitorEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "monitor_evaluations_total",
+	Help:      "Evaluations of DeploymentMonitor objects by result (success or failure).",
+}, []string{"result"})
+
+// BuildInfo is always 1; its labels describe the running binary
+var BuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: Namespace,
+	Name:      "build_info",
+	Help:      "Build information of the running binary; the value is always 1.",
+}, []string{"version", "commit", "go_version"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestsRejected,
+		ConfigReloads,
+		WatchRestarts,
+		Alerts,
+		AlertEvaluations,
+		Notifications,
+		MonitorEvaluations,
+		BuildInfo,
+	)
+
+	info := version.Get()
+	BuildInfo.WithLabelValues(info.Version, info.Commit, info.GoVersion).Set(1)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() fasthttp.RequestHandler {
+	return fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
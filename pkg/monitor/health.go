@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+)
+
+// defaultMinReadyPercent applies when a monitor sets no MinReadyPercent
+const defaultMinReadyPercent = 100
+
+// Validate checks a monitor spec and returns its deployment selector
+func Validate(spec v1alpha1.DeploymentMonitorSpec) (labels.Selector, error) {
+	var problems []string
+	selector, err := metav1.LabelSelectorAsSelector(&spec.Selector)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("selector: %v", err))
+	}
+	if p := spec.Thresholds.MinReadyPercent; p != nil && (*p < 0 || *p > 100) {
+		problems = append(problems, fmt.Sprintf("thresholds.minReadyPercent must be between 0 and 100, got %d", *p))
+	}
+	if m := spec.Thresholds.MaxUnavailable; m != nil && *m < 0 {
+		problems = append(problems, fmt.Sprintf("thresholds.maxUnavailable must not be negative, got %d", *m))
+	}
+	if spec.Interval != nil && spec.Interval.Duration <= 0 {
+		problems = append(problems, fmt.Sprintf("interval must be positive, got %s", spec.Interval.Duration))
+	}
+	if err := alerts.Validate(spec.Rules); err != nil {
+		problems = append(problems, "rules: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return selector, nil
+}
+
+// Health checks a deployment against the thresholds
+func Health(d *appsv1.Deployment, t v1alpha1.HealthThresholds) v1alpha1.MonitoredDeployment {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	result := v1alpha1.MonitoredDeployment{
+		Namespace:       d.Namespace,
+		Name:            d.Name,
+		ReadyReplicas:   d.Status.ReadyReplicas,
+		DesiredReplicas: desired,
+		Healthy:         true,
+	}
+
+	minReady := int32(defaultMinReadyPercent)
+	if t.MinReadyPercent != nil {
+		minReady = *t.MinReadyPercent
+	}
+	var reasons []string
+	if int64(d.Status.ReadyReplicas)*100 < int64(minReady)*int64(desired) {
+		reasons = append(reasons, fmt.Sprintf("%d of %d replicas ready, below %d%%", d.Status.ReadyReplicas, desired, minReady))
+	}
+	if t.MaxUnavailable != nil && d.Status.UnavailableReplicas > *t.MaxUnavailable {
+		reasons = append(reasons, fmt.Sprintf("%d replicas unavailable, above %d", d.Status.UnavailableReplicas, *t.MaxUnavailable))
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse {
+			reasons = append(reasons, "rollout stalled: "+c.Reason)
+		}
+	}
+	if len(reasons) > 0 {
+		result.Healthy = false
+		result.Reason = strings.Join(reasons, "; ")
+	}
+	return result
+}
+
+// sortDeployments orders unhealthy deployments first, then by namespace and
+// name
+func sortDeployments(deployments []v1alpha1.MonitoredDeployment) {
+	sort.Slice(deployments, func(i, j int) bool {
+		a, b := deployments[i], deployments[j]
+		if a.Healthy != b.Healthy {
+			return !a.Healthy
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
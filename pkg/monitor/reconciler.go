@@ -0,0 +1,478 @@
+// Package monitor reconciles DeploymentMonitor objects: it evaluates the
+// health thresholds and alerting rules of every monitor periodically and
+// publishes the result in the monitor's status.
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/pager"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/generated/informers/externalversions"
+)
+
+// Condition reasons of a DeploymentMonitor
+const (
+	ReasonEvaluated        = "Evaluated"
+	ReasonInvalidSpec      = "InvalidSpec"
+	ReasonEvaluationFailed = "EvaluationFailed"
+	ReasonAllHealthy       = "AllHealthy"
+	ReasonUnhealthy        = "Unhealthy"
+	ReasonNoDeployments    = "NoDeployments"
+)
+
+// Reconciler evaluates every DeploymentMonitor in a namespace. Each monitor
+// is evaluated by its own goroutine, so a slow monitor does not delay the
+// others; changes to a spec are evaluated at once.
+type Reconciler struct {
+	client    kubernetes.Interface
+	monitors  versioned.Interface
+	namespace string
+	pageSize  int64
+	interval  atomic.Int64
+	// crossNamespace holds the namespaces whose monitors may select other
+	// namespaces
+	crossNamespace atomic.Pointer[map[string]bool]
+
+	ctx     context.Context
+	mu      sync.Mutex
+	runners map[string]*runner
+
+	// stateMu guards the state of Run reported by Running and HasSynced
+	stateMu  sync.Mutex
+	running  bool
+	runErr   error
+	informer cache.SharedIndexInformer
+
+	// Changed, if set, is called when an alert of a monitor's rules fires or
+	// resolves
+	Changed func(monitor *v1alpha1.DeploymentMonitor, alert alerts.Alert)
+	// Evaluated, if set, is called after every evaluation with the status
+	// written, or the error that stopped it
+	Evaluated func(monitor *v1alpha1.DeploymentMonitor, status *v1alpha1.DeploymentMonitorStatus, err error)
+}
+
+// runner evaluates one monitor
+type runner struct {
+	monitor atomic.Pointer[v1alpha1.DeploymentMonitor]
+	// trigger requests an evaluation before the interval ends
+	trigger chan struct{}
+	cancel  context.CancelFunc
+	deleted atomic.Bool
+	// engines evaluate the rules, one per namespace; only the runner's
+	// goroutine uses them
+	engines map[string]*alerts.Engine
+}
+
+// NewReconciler creates a reconciler for the monitors in namespace; an empty
+// namespace means all namespaces. Monitors without an interval are evaluated
+// every interval.
+func NewReconciler(client kubernetes.Interface, monitors versioned.Interface, namespace string, pageSize int64, interval time.Duration) *Reconciler {
+	r := &Reconciler{
+		client:    client,
+		monitors:  monitors,
+		namespace: namespace,
+		pageSize:  pageSize,
+		runners:   make(map[string]*runner),
+	}
+	r.SetInterval(interval)
+	return r
+}
+
+// SetInterval changes the interval of monitors without one
+func (r *Reconciler) SetInterval(interval time.Duration) {
+	r.interval.Store(int64(interval))
+}
+
+// SetCrossNamespace sets the namespaces whose monitors may select
+// deployments in other namespaces. The controller evaluates monitors with its
+// own permissions and publishes the result in their status, so a monitor in
+// any other namespace may only select its own namespace. Running monitors are
+// evaluated again at once.
+func (r *Reconciler) SetCrossNamespace(namespaces []string) {
+	allowed := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		allowed[namespace] = true
+	}
+	r.crossNamespace.Store(&allowed)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runners {
+		select {
+		case run.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Running returns nil while Run runs. Otherwise it returns the error Run
+// failed with, or an error saying that it has not started or has stopped.
+func (r *Reconciler) Running() error {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	switch {
+	case r.running:
+		return nil
+	case r.runErr != nil:
+		return r.runErr
+	}
+	return fmt.Errorf("the reconciler is not running")
+}
+
+// HasSynced reports whether the informer of Run has listed the monitors
+func (r *Reconciler) HasSynced() bool {
+	r.stateMu.Lock()
+	informer := r.informer
+	r.stateMu.Unlock()
+	return informer != nil && informer.HasSynced()
+}
+
+// Run watches the monitors and evaluates them until ctx is cancelled. It
+// fails when the monitors cannot be listed, e.g. because the CRD is missing.
+func (r *Reconciler) Run(ctx context.Context) (err error) {
+	r.stateMu.Lock()
+	r.running, r.runErr = true, nil
+	r.stateMu.Unlock()
+	defer func() {
+		r.stateMu.Lock()
+		r.running, r.runErr = false, err
+		r.stateMu.Unlock()
+	}()
+
+	r.ctx = ctx
+	_, err = r.monitors.MonitoringV1alpha1().DeploymentMonitors(r.namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %v", v1alpha1.DeploymentMonitorResource.GroupResource(), err)
+	}
+
+	factory := externalversions.NewSharedInformerFactoryWithOptions(r.monitors, 0, externalversions.WithNamespace(r.namespace))
+	informer := factory.Monitoring().V1alpha1().DeploymentMonitors().Informer()
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.apply,
+		UpdateFunc: func(_, obj interface{}) { r.apply(obj) },
+		DeleteFunc: r.remove,
+	})
+	if err != nil {
+		return err
+	}
+	r.stateMu.Lock()
+	r.informer = informer
+	r.stateMu.Unlock()
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	// Waiting only fails when ctx is cancelled, which is no error
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) && ctx.Err() == nil {
+		return fmt.Errorf("failed to list %s", v1alpha1.DeploymentMonitorResource.GroupResource())
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// apply starts a runner for a new monitor and re-evaluates a monitor whose
+// spec changed
+func (r *Reconciler) apply(obj interface{}) {
+	m, ok := obj.(*v1alpha1.DeploymentMonitor)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := m.Namespace + "/" + m.Name
+	if run, ok := r.runners[key]; ok {
+		previous := run.monitor.Swap(m)
+		// Status updates, including our own, do not change the generation
+		if previous.Generation != m.Generation {
+			select {
+			case run.trigger <- struct{}{}:
+			default:
+			}
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	run := &runner{
+		trigger: make(chan struct{}, 1),
+		cancel:  cancel,
+		engines: make(map[string]*alerts.Engine),
+	}
+	run.monitor.Store(m)
+	r.runners[key] = run
+	go r.run(ctx, run)
+}
+
+// remove stops the runner of a deleted monitor
+func (r *Reconciler) remove(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, ok := obj.(*v1alpha1.DeploymentMonitor)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := m.Namespace + "/" + m.Name
+	if run, ok := r.runners[key]; ok {
+		run.deleted.Store(true)
+		run.cancel()
+		delete(r.runners, key)
+	}
+}
+
+// run evaluates a monitor every interval until its context is cancelled
+func (r *Reconciler) run(ctx context.Context, run *runner) {
+	defer func() {
+		// The firing alerts of a deleted monitor resolve
+		if run.deleted.Load() {
+			for _, engine := range run.engines {
+				engine.SetRules(nil)
+			}
+		}
+	}()
+
+	for {
+		m := run.monitor.Load()
+		status, err := r.evaluate(ctx, run, m)
+		if ctx.Err() != nil {
+			return
+		}
+		if r.Evaluated != nil {
+			r.Evaluated(m, status, err)
+		}
+
+		interval := time.Duration(r.interval.Load())
+		if m.Spec.Interval != nil && m.Spec.Interval.Duration > 0 {
+			interval = m.Spec.Interval.Duration
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-run.trigger:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// evaluate checks the selected deployments and rules of m and writes the
+// status. The status is written even when the evaluation fails, so that the
+// failure shows in the Ready condition.
+func (r *Reconciler) evaluate(ctx context.Context, run *runner, m *v1alpha1.DeploymentMonitor) (*v1alpha1.DeploymentMonitorStatus, error) {
+	now := metav1.Now()
+	status := &v1alpha1.DeploymentMonitorStatus{
+		ObservedGeneration: m.Generation,
+		LastEvaluationTime: &now,
+		// The cached object must not be modified
+		Conditions: m.Status.DeepCopy().Conditions,
+	}
+
+	err := r.check(ctx, run, m, status)
+	if err != nil {
+		reason := ReasonEvaluationFailed
+		if _, invalid := err.(invalidSpecError); invalid {
+			reason = ReasonInvalidSpec
+		}
+		setCondition(status, m, v1alpha1.ConditionReady, metav1.ConditionFalse, reason, err.Error())
+	} else {
+		setCondition(status, m, v1alpha1.ConditionReady, metav1.ConditionTrue, ReasonEvaluated, "The monitor was evaluated")
+	}
+
+	if patchErr := r.patchStatus(ctx, m, status); patchErr != nil && err == nil {
+		err = patchErr
+	}
+	return status, err
+}
+
+// invalidSpecError is a spec that cannot be evaluated
+type invalidSpecError struct {
+	err error
+}
+
+func (e invalidSpecError) Error() string { return "invalid spec: " + e.err.Error() }
+
+// check fills in the deployments, alerts and Healthy condition of status
+func (r *Reconciler) check(ctx context.Context, run *runner, m *v1alpha1.DeploymentMonitor, status *v1alpha1.DeploymentMonitorStatus) error {
+	selector, err := Validate(m.Spec)
+	if err != nil {
+		return invalidSpecError{err: err}
+	}
+	namespaces := m.Spec.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{m.Namespace}
+	}
+	if err := r.checkNamespaces(m, namespaces); err != nil {
+		// The alerts of namespaces selected before resolve
+		r.syncEngines(run, m, nil, selector)
+		return invalidSpecError{err: err}
+	}
+	if err := r.syncEngines(run, m, namespaces, selector); err != nil {
+		return invalidSpecError{err: err}
+	}
+
+	var deployments []v1alpha1.MonitoredDeployment
+	for _, namespace := range namespaces {
+		err := r.listDeployments(ctx, namespace, selector, func(d *appsv1.Deployment) {
+			deployments = append(deployments, Health(d, m.Spec.Thresholds))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list deployments in %s: %v", namespace, err)
+		}
+	}
+	status.MatchedDeployments = int32(len(deployments))
+	for _, d := range deployments {
+		if d.Healthy {
+			status.HealthyDeployments++
+		}
+	}
+	sortDeployments(deployments)
+	if len(deployments) > v1alpha1.MaxStatusEntries {
+		deployments = deployments[:v1alpha1.MaxStatusEntries]
+	}
+	status.Deployments = deployments
+
+	unhealthy := status.MatchedDeployments - status.HealthyDeployments
+	switch {
+	case status.MatchedDeployments == 0:
+		setCondition(status, m, v1alpha1.ConditionHealthy, metav1.ConditionTrue, ReasonNoDeployments, "No deployments are selected")
+	case unhealthy == 0:
+		setCondition(status, m, v1alpha1.ConditionHealthy, metav1.ConditionTrue, ReasonAllHealthy,
+			fmt.Sprintf("All %d deployments are healthy", status.MatchedDeployments))
+	default:
+		setCondition(status, m, v1alpha1.ConditionHealthy, metav1.ConditionFalse, ReasonUnhealthy,
+			fmt.Sprintf("%d of %d deployments are unhealthy", unhealthy, status.MatchedDeployments))
+	}
+
+	for _, namespace := range namespaces {
+		engine := run.engines[namespace]
+		if err := engine.Evaluate(ctx); err != nil {
+			return fmt.Errorf("failed to evaluate rules in %s: %v", namespace, err)
+		}
+		for _, alert := range engine.Alerts() {
+			if alert.State != alerts.StateFiring || len(status.FiringAlerts) >= v1alpha1.MaxStatusEntries {
+				continue
+			}
+			status.FiringAlerts = append(status.FiringAlerts, v1alpha1.FiringAlert{
+				Rule:      alert.Rule,
+				Severity:  alert.Severity,
+				Namespace: alert.Namespace,
+				Kind:      alert.Kind,
+				Name:      alert.Name,
+				Message:   alert.Message,
+				Since:     metav1.NewTime(*alert.FiredAt),
+			})
+		}
+	}
+	return nil
+}
+
+// checkNamespaces fails when m selects a namespace other than its own
+// without being allowed to
+func (r *Reconciler) checkNamespaces(m *v1alpha1.DeploymentMonitor, namespaces []string) error {
+	if allowed := r.crossNamespace.Load(); allowed != nil && (*allowed)[m.Namespace] {
+		return nil
+	}
+	for _, namespace := range namespaces {
+		if namespace != m.Namespace {
+			return fmt.Errorf("namespaces: monitors in namespace %s may only select %s, not %s", m.Namespace, m.Namespace, namespace)
+		}
+	}
+	return nil
+}
+
+// syncEngines keeps one engine per namespace with the rules of m. The alerts
+// of namespaces no longer monitored resolve.
+func (r *Reconciler) syncEngines(run *runner, m *v1alpha1.DeploymentMonitor, namespaces []string, selector labels.Selector) error {
+	monitored := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		monitored[namespace] = true
+		engine, ok := run.engines[namespace]
+		if !ok {
+			engine = alerts.NewEngine(r.client, namespace, r.pageSize, 0)
+			engine.Changed = func(alert alerts.Alert) {
+				if r.Changed != nil {
+					r.Changed(run.monitor.Load(), alert)
+				}
+			}
+			run.engines[namespace] = engine
+		}
+		engine.Selector = selector
+		if err := engine.SetRules(m.Spec.Rules); err != nil {
+			return err
+		}
+	}
+	for namespace, engine := range run.engines {
+		if !monitored[namespace] {
+			engine.SetRules(nil)
+			delete(run.engines, namespace)
+		}
+	}
+	return nil
+}
+
+// listDeployments pages through the deployments of namespace that selector
+// selects
+func (r *Reconciler) listDeployments(ctx context.Context, namespace string, selector labels.Selector, add func(d *appsv1.Deployment)) error {
+	p := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return r.client.AppsV1().Deployments(namespace).List(ctx, opts)
+	}))
+	p.PageSize = r.pageSize
+	return p.EachListItem(ctx, metav1.ListOptions{LabelSelector: selector.String()}, func(obj runtime.Object) error {
+		d, ok := obj.(*appsv1.Deployment)
+		if !ok {
+			return fmt.Errorf("unexpected object %T", obj)
+		}
+		add(d)
+		return nil
+	})
+}
+
+// patchStatus replaces the status of m. A monitor deleted meanwhile is not
+// an error.
+func (r *Reconciler) patchStatus(ctx context.Context, m *v1alpha1.DeploymentMonitor, status *v1alpha1.DeploymentMonitorStatus) error {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+	_, err = r.monitors.MonitoringV1alpha1().DeploymentMonitors(m.Namespace).
+		Patch(ctx, m.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to update status: %v", err)
+	}
+	return nil
+}
+
+// setCondition sets a condition of status; the transition time only changes
+// with the condition's status
+func setCondition(status *v1alpha1.DeploymentMonitorStatus, m *v1alpha1.DeploymentMonitor, conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		ObservedGeneration: m.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
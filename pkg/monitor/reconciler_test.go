@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/apis/monitoring/v1alpha1"
+	monitorfake "github.com/yourusername/k8s-controller-tutorial/pkg/generated/clientset/versioned/fake"
+)
+
+// evaluateMonitor evaluates a monitor in namespace team that selects
+// namespaces, with a deployment in team and one in kube-system
+func evaluateMonitor(t *testing.T, crossNamespace []string, namespaces ...string) *v1alpha1.DeploymentMonitorStatus {
+	t.Helper()
+	replicas := int32(1)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "web"}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns"}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}},
+	)
+	m := &v1alpha1.DeploymentMonitor{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "mon", Generation: 1},
+		Spec:       v1alpha1.DeploymentMonitorSpec{Namespaces: namespaces},
+	}
+	r := NewReconciler(client, monitorfake.NewSimpleClientset(m), "", 100, time.Minute)
+	r.SetCrossNamespace(crossNamespace)
+
+	run := &runner{engines: make(map[string]*alerts.Engine)}
+	run.monitor.Store(m)
+	status, _ := r.evaluate(context.Background(), run, m)
+	return status
+}
+
+func TestMonitorNamespaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		crossNamespace []string
+		namespaces     []string
+		reason         string
+		deployments    []string
+	}{
+		{name: "default namespace", reason: ReasonEvaluated, deployments: []string{"team/web"}},
+		{name: "own namespace", namespaces: []string{"team"}, reason: ReasonEvaluated, deployments: []string{"team/web"}},
+		{name: "other namespace", namespaces: []string{"team", "kube-system"}, reason: ReasonInvalidSpec},
+		{name: "other namespace, other monitor namespace allowed", crossNamespace: []string{"ops"}, namespaces: []string{"kube-system"}, reason: ReasonInvalidSpec},
+		{name: "other namespace allowed", crossNamespace: []string{"team"}, namespaces: []string{"team", "kube-system"}, reason: ReasonEvaluated, deployments: []string{"kube-system/coredns", "team/web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := evaluateMonitor(t, tt.crossNamespace, tt.namespaces...)
+
+			ready := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionReady)
+			if ready == nil || ready.Reason != tt.reason {
+				t.Fatalf("Ready condition = %+v, want reason %s", ready, tt.reason)
+			}
+			if tt.reason == ReasonInvalidSpec && !strings.Contains(ready.Message, "kube-system") {
+				t.Errorf("message %q does not name the namespace", ready.Message)
+			}
+			var deployments []string
+			for _, d := range status.Deployments {
+				deployments = append(deployments, d.Namespace+"/"+d.Name)
+			}
+			if strings.Join(deployments, ",") != strings.Join(tt.deployments, ",") {
+				t.Errorf("deployments = %v, want %v", deployments, tt.deployments)
+			}
+		})
+	}
+}
+
+func TestReconcilerRunState(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), monitorfake.NewSimpleClientset(), "", 100, time.Minute)
+	if r.Running() == nil || r.HasSynced() {
+		t.Fatal("a reconciler that was not started reports running or synced")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !r.HasSynced() || r.Running() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("not synced: running %v", r.Running())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if r.Running() == nil {
+		t.Error("a stopped reconciler reports running")
+	}
+}
+
+func TestReconcilerRunFailure(t *testing.T) {
+	monitors := monitorfake.NewSimpleClientset()
+	monitors.PrependReactor("list", "deploymentmonitors", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(v1alpha1.DeploymentMonitorResource.GroupResource(), "")
+	})
+	r := NewReconciler(fake.NewSimpleClientset(), monitors, "", 100, time.Minute)
+
+	err := r.Run(context.Background())
+	if err == nil {
+		t.Fatal("Run succeeded without the CRD")
+	}
+	if running := r.Running(); running == nil || running.Error() != err.Error() {
+		t.Errorf("Running() = %v, want %v", running, err)
+	}
+	if r.HasSynced() {
+		t.Error("HasSynced() = true after Run failed")
+	}
+}
@@ -0,0 +1,285 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// File is the format of a notifications file:
+//
+//	sinks:
+//	  - name: team-web
+//	    slack: {webhookURL: "${SLACK_WEBHOOK_URL}"}
+//	    route: {namespaces: [web], severities: [critical]}
+type File struct {
+	Sinks []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig is one destination of notifications. Exactly one of Slack,
+// Webhook, Email and PagerDuty must be set.
+type SinkConfig struct {
+	Name     string         `json:"name"`
+	Route    Route          `json:"route,omitempty"`
+	Template TemplateConfig `json:"template,omitempty"`
+	Retry    RetryConfig    `json:"retry,omitempty"`
+	// SkipResolved sends only firing alerts
+	SkipResolved bool `json:"skipResolved,omitempty"`
+
+	Slack     *SlackConfig     `json:"slack,omitempty"`
+	Webhook   *WebhookConfig   `json:"webhook,omitempty"`
+	Email     *EmailConfig     `json:"email,omitempty"`
+	PagerDuty *PagerDutyConfig `json:"pagerduty,omitempty"`
+}
+
+// Route selects the alerts a sink receives; empty lists match everything
+type Route struct {
+	Namespaces []string `json:"namespaces,omitempty"`
+	Severities []string `json:"severities,omitempty"`
+}
+
+// Matches reports whether alert is routed to the sink
+func (r Route) Matches(alert alerts.Alert) bool {
+	return (len(r.Namespaces) == 0 || contains(r.Namespaces, alert.Namespace)) &&
+		(len(r.Severities) == 0 || contains(r.Severities, alert.Severity))
+}
+
+// TemplateConfig overrides the message templates. They are Go templates
+// executed with the alerts.Alert, e.g. "{{ .Rule }} is {{ .State }}".
+type TemplateConfig struct {
+	// Title is the Slack headline, the email subject and the PagerDuty summary
+	Title string `json:"title,omitempty"`
+	// Text is the message body
+	Text string `json:"text,omitempty"`
+}
+
+// RetryConfig controls redelivery of failed notifications
+type RetryConfig struct {
+	// Attempts is the number of deliveries before the notification is
+	// dead-lettered (default 5)
+	Attempts int `json:"attempts,omitempty"`
+	// InitialBackoff doubles after every attempt up to MaxBackoff (defaults
+	// 1s and 1m)
+	InitialBackoff metav1.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff     metav1.Duration `json:"maxBackoff,omitempty"`
+	// Timeout bounds each attempt (default 10s)
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SlackConfig posts to a Slack incoming webhook
+type SlackConfig struct {
+	WebhookURL string `json:"webhookURL" secret:"true"`
+	// Channel overrides the webhook's channel, if the webhook allows it
+	Channel string `json:"channel,omitempty"`
+}
+
+// WebhookConfig posts the alert as JSON. With Secret the body is signed with
+// HMAC-SHA256 in the X-Signature-256 header.
+type WebhookConfig struct {
+	URL     string            `json:"url" secret:"true"`
+	Secret  string            `json:"secret,omitempty" secret:"true"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// EmailConfig sends mail over SMTP. STARTTLS is used when the server offers
+// it; authentication requires it unless the server is on localhost.
+type EmailConfig struct {
+	// Smarthost is the SMTP server as host:port
+	Smarthost string   `json:"smarthost"`
+	From      string   `json:"from"`
+	To        []string `json:"to"`
+	Username  string   `json:"username,omitempty"`
+	Password  string   `json:"password,omitempty" secret:"true"`
+	// RequireTLS fails when the server does not offer STARTTLS
+	RequireTLS bool `json:"requireTLS,omitempty"`
+}
+
+// PagerDutyConfig sends PagerDuty Events API v2 events
+type PagerDutyConfig struct {
+	RoutingKey string `json:"routingKey" secret:"true"`
+	// URL defaults to the PagerDuty Events API
+	URL string `json:"url,omitempty"`
+}
+
+// Defaults of RetryConfig
+const (
+	defaultAttempts       = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultTimeout        = 10 * time.Second
+)
+
+// Load reads and validates a notifications file. Values of fields marked
+// secret may reference environment variables as ${NAME}, so that secrets
+// need not be written into the file. Unknown keys are an error.
+func Load(path string) ([]SinkConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %v", err)
+	}
+	var file File
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse notifications %s: %v", path, err)
+	}
+	for i := range file.Sinks {
+		if err := expandSecrets(reflect.ValueOf(&file.Sinks[i]).Elem()); err != nil {
+			return nil, fmt.Errorf("notifications %s: sink %q: %v", path, file.Sinks[i].Name, err)
+		}
+	}
+	if err := Validate(file.Sinks); err != nil {
+		return nil, fmt.Errorf("notifications %s: %v", path, err)
+	}
+	return file.Sinks, nil
+}
+
+// Validate reports every problem of sinks at once
+func Validate(sinks []SinkConfig) error {
+	var problems []string
+	names := make(map[string]bool)
+	for i, s := range sinks {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("sink %d", i)
+			problems = append(problems, name+": name is required")
+		} else if names[name] {
+			problems = append(problems, name+": duplicate name")
+		}
+		names[name] = true
+
+		if err := s.validate(); err != nil {
+			problems = append(problems, name+": "+err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid sinks: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s SinkConfig) validate() error {
+	for _, severity := range s.Route.Severities {
+		if !contains(alerts.Severities, severity) {
+			return fmt.Errorf("route.severities: unknown severity %q", severity)
+		}
+	}
+	if _, err := newTemplates(s.Template, TemplateConfig{}); err != nil {
+		return err
+	}
+	r := s.Retry
+	if r.Attempts < 0 || r.InitialBackoff.Duration < 0 || r.MaxBackoff.Duration < 0 || r.Timeout.Duration < 0 {
+		return fmt.Errorf("retry settings must not be negative")
+	}
+
+	kinds := 0
+	if c := s.Slack; c != nil {
+		kinds++
+		if err := validURL("slack.webhookURL", c.WebhookURL); err != nil {
+			return err
+		}
+	}
+	if c := s.Webhook; c != nil {
+		kinds++
+		if err := validURL("webhook.url", c.URL); err != nil {
+			return err
+		}
+	}
+	if c := s.Email; c != nil {
+		kinds++
+		if !strings.Contains(c.Smarthost, ":") {
+			return fmt.Errorf("email.smarthost must be host:port, got %q", c.Smarthost)
+		}
+		if c.From == "" || len(c.To) == 0 {
+			return fmt.Errorf("email.from and email.to are required")
+		}
+	}
+	if c := s.PagerDuty; c != nil {
+		kinds++
+		if c.RoutingKey == "" {
+			return fmt.Errorf("pagerduty.routingKey is required")
+		}
+		if c.URL != "" {
+			if err := validURL("pagerduty.url", c.URL); err != nil {
+				return err
+			}
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of slack, webhook, email or pagerduty must be set")
+	}
+	return nil
+}
+
+// retry returns the retry settings with defaults filled in
+func (s SinkConfig) retry() RetryConfig {
+	r := s.Retry
+	if r.Attempts == 0 {
+		r.Attempts = defaultAttempts
+	}
+	if r.InitialBackoff.Duration == 0 {
+		r.InitialBackoff.Duration = defaultInitialBackoff
+	}
+	if r.MaxBackoff.Duration == 0 {
+		r.MaxBackoff.Duration = defaultMaxBackoff
+	}
+	if r.Timeout.Duration == 0 {
+		r.Timeout.Duration = defaultTimeout
+	}
+	return r
+}
+
+// expandSecrets replaces ${NAME} in fields tagged secret:"true" with the
+// environment variable NAME. A variable that is not set is an error.
+func expandSecrets(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		switch {
+		case value.Kind() == reflect.Struct:
+			if err := expandSecrets(value); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.String && field.Tag.Get("secret") == "true":
+			var missing []string
+			expanded := os.Expand(value.String(), func(name string) string {
+				env, ok := os.LookupEnv(name)
+				if !ok {
+					missing = append(missing, name)
+				}
+				return env
+			})
+			if len(missing) > 0 {
+				return fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+			}
+			value.SetString(expanded)
+		}
+	}
+	return nil
+}
+
+func validURL(key, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an http or https URL", key)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
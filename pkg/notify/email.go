@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// emailSink sends a plain text mail per notification
+type emailSink struct {
+	config    EmailConfig
+	templates *templates
+}
+
+func (s *emailSink) Send(ctx context.Context, alert alerts.Alert) error {
+	subject, text, err := s.templates.render(alert)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return s.send(ctx, msg.Bytes())
+}
+
+// send delivers msg like smtp.SendMail, but bounded by the deadline of ctx
+func (s *emailSink) send(ctx context.Context, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.config.Smarthost)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Smarthost)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	} else if s.config.RequireTLS {
+		return &permanentError{err: fmt.Errorf("%s does not offer STARTTLS", s.config.Smarthost)}
+	}
+
+	if s.config.Username != "" {
+		// PlainAuth refuses to send the password without TLS, except to
+		// localhost
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, host)
+		if err := client.Auth(auth); err != nil {
+			if _, ok := err.(*textproto.Error); !ok {
+				return &permanentError{err: err}
+			}
+			return smtpError(err)
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return smtpError(err)
+	}
+	for _, to := range s.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return smtpError(err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+// smtpError marks permanent SMTP failures (5xx replies) as such
+func smtpError(err error) error {
+	if protoErr, ok := err.(*textproto.Error); ok && protoErr.Code >= 500 {
+		return &permanentError{err: err}
+	}
+	return err
+}
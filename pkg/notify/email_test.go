@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal SMTP stand-in without STARTTLS that answers RCPT
+// with rcptReply and records the mail data
+type smtpServer struct {
+	addr string
+	data chan string
+}
+
+func newSMTPServer(t *testing.T, rcptReply string) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &smtpServer{addr: listener.Addr().String(), data: make(chan string, 1)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, rcptReply)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn, rcptReply string) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP test")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+		case "EHLO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			tp.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			tp.PrintfLine("%s", rcptReply)
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.data <- strings.Join(lines, "\n")
+			tp.PrintfLine("250 2.0.0 queued")
+		case "QUIT":
+			tp.PrintfLine("221 2.0.0 bye")
+			return
+		default:
+			tp.PrintfLine("502 5.5.2 %s not implemented", verb)
+		}
+	}
+}
+
+func newEmailSink(t *testing.T, config EmailConfig) Sink {
+	t.Helper()
+	config.From = "alerts@example.com"
+	config.To = []string{"oncall@example.com"}
+	return newTestSink(t, SinkConfig{Name: "mail", Email: &config})
+}
+
+func TestEmailSinkDelivers(t *testing.T) {
+	server := newSMTPServer(t, "250 2.1.5 OK")
+	sink := newEmailSink(t, EmailConfig{Smarthost: server.addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Send(ctx, testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	data := <-server.data
+	for _, want := range []string{"From: alerts@example.com", "To: oncall@example.com", "Subject: ", "NotReady"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestEmailSinkClassifiesReplies(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    EmailConfig
+		rcptReply string
+		permanent bool
+	}{
+		{name: "mailbox unavailable", rcptReply: "550 5.1.1 no such user", permanent: true},
+		{name: "greylisted", rcptReply: "451 4.7.1 try again later"},
+		{name: "no STARTTLS", config: EmailConfig{RequireTLS: true}, rcptReply: "250 2.1.5 OK", permanent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPServer(t, tt.rcptReply)
+			tt.config.Smarthost = server.addr
+			sink := newEmailSink(t, tt.config)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := sink.Send(ctx, testAlert())
+			if err == nil {
+				t.Fatal("Send() succeeded")
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, IsPermanent(err), tt.permanent)
+			}
+		})
+	}
+}
+
+func TestSMTPError(t *testing.T) {
+	if !IsPermanent(smtpError(&textproto.Error{Code: 554, Msg: "rejected"})) {
+		t.Error("5xx replies must be permanent")
+	}
+	if IsPermanent(smtpError(&textproto.Error{Code: 421, Msg: "closing"})) {
+		t.Error("4xx replies must be retried")
+	}
+	if IsPermanent(smtpError(io.ErrUnexpectedEOF)) {
+		t.Error("connection errors must be retried")
+	}
+}
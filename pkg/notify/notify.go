@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// queueSize is the number of notifications a sink may fall behind before
+// further ones are dead-lettered
+const queueSize = 100
+
+// DeadLetter is a notification that could not be delivered
+type DeadLetter struct {
+	Time     time.Time    `json:"time"`
+	Sink     string       `json:"sink"`
+	Alert    alerts.Alert `json:"alert"`
+	Attempts int          `json:"attempts"`
+	Error    string       `json:"error"`
+}
+
+// Dispatcher routes alerts to sinks. Every sink has its own queue and
+// worker, so a slow or failing sink does not delay the others. Failed
+// deliveries are retried with backoff, then dead-lettered.
+type Dispatcher struct {
+	ctx context.Context
+
+	mu      sync.RWMutex
+	workers []*worker
+	// wg tracks the workers, including the ones replaced by SetSinks
+	wg sync.WaitGroup
+
+	// Sent, if set, is called after a notification was delivered
+	Sent func(sink string, alert alerts.Alert, attempts int)
+	// Failed, if set, is called after every failed attempt
+	Failed func(sink string, alert alerts.Alert, attempt int, err error)
+	// DeadLettered, if set, is called for every notification given up on
+	DeadLettered func(letter DeadLetter)
+	// HTTPClient, if set, sends the requests of the sinks created by the
+	// following calls to SetSinks
+	HTTPClient *http.Client
+}
+
+// worker delivers the notifications of one sink
+type worker struct {
+	config SinkConfig
+	sink   Sink
+	queue  chan alerts.Alert
+}
+
+// NewDispatcher creates a dispatcher without sinks. Workers stop when ctx is
+// cancelled.
+func NewDispatcher(ctx context.Context) *Dispatcher {
+	return &Dispatcher{ctx: ctx}
+}
+
+// SetSinks replaces the sinks. Notifications queued for the old sinks are
+// still delivered.
+func (d *Dispatcher) SetSinks(configs []SinkConfig) error {
+	if err := Validate(configs); err != nil {
+		return err
+	}
+	workers := make([]*worker, 0, len(configs))
+	for _, c := range configs {
+		sink, err := NewSink(c, d.HTTPClient)
+		if err != nil {
+			return fmt.Errorf("sink %q: %v", c.Name, err)
+		}
+		workers = append(workers, &worker{config: c, sink: sink, queue: make(chan alerts.Alert, queueSize)})
+	}
+
+	d.mu.Lock()
+	old := d.workers
+	d.workers = workers
+	for _, w := range workers {
+		d.wg.Add(1)
+		go d.run(w)
+	}
+	d.mu.Unlock()
+
+	for _, w := range old {
+		close(w.queue)
+	}
+	return nil
+}
+
+// Notify queues alert for every sink whose route matches
+func (d *Dispatcher) Notify(alert alerts.Alert) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, w := range d.workers {
+		if !w.config.Route.Matches(alert) || (w.config.SkipResolved && alert.State == alerts.StateResolved) {
+			continue
+		}
+		d.enqueue(w, alert)
+	}
+}
+
+// NotifySinks queues alert for the named sinks, whatever their routes, and
+// returns the names that match no sink
+func (d *Dispatcher) NotifySinks(alert alerts.Alert, names []string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var unknown []string
+	for _, name := range names {
+		found := false
+		for _, w := range d.workers {
+			if w.config.Name != name {
+				continue
+			}
+			found = true
+			if !w.config.SkipResolved || alert.State != alerts.StateResolved {
+				d.enqueue(w, alert)
+			}
+			break
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// enqueue queues alert for w without blocking; a full queue dead-letters it
+func (d *Dispatcher) enqueue(w *worker, alert alerts.Alert) {
+	select {
+	case w.queue <- alert:
+	default:
+		d.deadLetter(w, alert, 0, fmt.Errorf("queue full: %d notifications pending", queueSize))
+	}
+}
+
+// Wait blocks until every queued notification was delivered or given up on,
+// or ctx is cancelled. The dispatcher takes no notifications afterwards.
+func (d *Dispatcher) Wait(ctx context.Context) error {
+	d.mu.Lock()
+	old := d.workers
+	d.workers = nil
+	d.mu.Unlock()
+	for _, w := range old {
+		close(w.queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run(w *worker) {
+	defer d.wg.Done()
+	for alert := range w.queue {
+		d.deliver(w, alert)
+	}
+}
+
+// deliver sends alert to the sink of w, retrying with exponential backoff
+// and jitter
+func (d *Dispatcher) deliver(w *worker, alert alerts.Alert) {
+	retry := w.config.retry()
+	backoff := wait.Backoff{
+		Duration: retry.InitialBackoff.Duration,
+		Factor:   2,
+		Jitter:   0.2,
+		Steps:    math.MaxInt32,
+		Cap:      retry.MaxBackoff.Duration,
+	}
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(d.ctx, retry.Timeout.Duration)
+		err := w.sink.Send(ctx, alert)
+		cancel()
+		if err == nil {
+			if d.Sent != nil {
+				d.Sent(w.config.Name, alert, attempt)
+			}
+			return
+		}
+		if d.Failed != nil {
+			d.Failed(w.config.Name, alert, attempt, err)
+		}
+		if IsPermanent(err) || attempt >= retry.Attempts || d.ctx.Err() != nil {
+			d.deadLetter(w, alert, attempt, err)
+			return
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			d.deadLetter(w, alert, attempt, d.ctx.Err())
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (d *Dispatcher) deadLetter(w *worker, alert alerts.Alert, attempts int, err error) {
+	if d.DeadLettered != nil {
+		d.DeadLettered(DeadLetter{
+			Time:     time.Now().UTC(),
+			Sink:     w.config.Name,
+			Alert:    alert,
+			Attempts: attempts,
+			Error:    err.Error(),
+		})
+	}
+}
+
+// DeadLetterFile appends dead letters to a file as JSON lines, so that they
+// can be inspected and replayed
+type DeadLetterFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenDeadLetterFile opens path for appending, creating it if needed
+func OpenDeadLetterFile(path string) (*DeadLetterFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead letter file: %v", err)
+	}
+	return &DeadLetterFile{file: file}, nil
+}
+
+// Write appends letter as one line
+func (f *DeadLetterFile) Write(letter DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := bufio.NewWriter(f.file)
+	w.Write(data)
+	w.WriteByte('\n')
+	return w.Flush()
+}
+
+// Close closes the file
+func (f *DeadLetterFile) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// outcomes records the callbacks of a dispatcher
+type outcomes struct {
+	mu      sync.Mutex
+	sent    []int
+	failed  int
+	letters []DeadLetter
+}
+
+func newTestDispatcher(t *testing.T, url string, attempts int) (*Dispatcher, *outcomes) {
+	t.Helper()
+	d := NewDispatcher(context.Background())
+	o := &outcomes{}
+	d.Sent = func(sink string, alert alerts.Alert, attempts int) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.sent = append(o.sent, attempts)
+	}
+	d.Failed = func(sink string, alert alerts.Alert, attempt int, err error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.failed++
+	}
+	d.DeadLettered = func(letter DeadLetter) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.letters = append(o.letters, letter)
+	}
+
+	err := d.SetSinks([]SinkConfig{{
+		Name:    "hook",
+		Webhook: &WebhookConfig{URL: url},
+		Retry: RetryConfig{
+			Attempts:       attempts,
+			InitialBackoff: metav1.Duration{Duration: time.Millisecond},
+			MaxBackoff:     metav1.Duration{Duration: 5 * time.Millisecond},
+			Timeout:        metav1.Duration{Duration: 5 * time.Second},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, o
+}
+
+// drain waits for the dispatcher to finish its deliveries
+func drain(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := d.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newSequenceServer answers with statuses in turn, then with the last one
+func newSequenceServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int32
+		sent     []int
+		failed   int
+		letter   int
+	}{
+		{name: "server error, then delivered", statuses: []int{500, 200}, requests: 2, sent: []int{2}, failed: 1},
+		{name: "throttled, then delivered", statuses: []int{429, 429, 202}, requests: 3, sent: []int{3}, failed: 2},
+		{name: "rejected", statuses: []int{400}, requests: 1, failed: 1, letter: 1},
+		{name: "attempts exhausted", statuses: []int{503}, requests: 3, failed: 3, letter: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newSequenceServer(t, tt.statuses...)
+			d, o := newTestDispatcher(t, server.URL, 3)
+
+			d.Notify(testAlert())
+			drain(t, d)
+
+			if got := atomic.LoadInt32(requests); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+			if fmt.Sprint(o.sent) != fmt.Sprint(tt.sent) || o.failed != tt.failed {
+				t.Errorf("sent after %v attempts with %d failures, want %v and %d", o.sent, o.failed, tt.sent, tt.failed)
+			}
+			if tt.letter == 0 {
+				if len(o.letters) != 0 {
+					t.Errorf("unexpected dead letters %+v", o.letters)
+				}
+				return
+			}
+			if len(o.letters) != 1 || o.letters[0].Attempts != tt.letter || o.letters[0].Sink != "hook" {
+				t.Fatalf("dead letters = %+v, want one after %d attempts", o.letters, tt.letter)
+			}
+			if !strings.Contains(o.letters[0].Error, fmt.Sprint(tt.statuses[len(tt.statuses)-1])) {
+				t.Errorf("dead letter error %q does not name the answer", o.letters[0].Error)
+			}
+		})
+	}
+}
+
+func TestDispatcherDeadLettersWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	d, o := newTestDispatcher(t, server.URL, 1)
+
+	// The worker holds at most one notification, the queue queueSize
+	total := queueSize + 5
+	for i := 0; i < total; i++ {
+		alert := testAlert()
+		alert.Name = fmt.Sprintf("web-%d", i)
+		d.Notify(alert)
+	}
+
+	o.mu.Lock()
+	letters := append([]DeadLetter(nil), o.letters...)
+	o.mu.Unlock()
+	if len(letters) < total-queueSize-1 || len(letters) > total-queueSize {
+		t.Errorf("%d dead letters, want %d or %d", len(letters), total-queueSize-1, total-queueSize)
+	}
+	for _, letter := range letters {
+		if letter.Attempts != 0 || !strings.Contains(letter.Error, "queue full") {
+			t.Errorf("unexpected dead letter %+v", letter)
+		}
+	}
+	if last := letters[len(letters)-1]; last.Alert.Name != fmt.Sprintf("web-%d", total-1) {
+		t.Errorf("dead-lettered %s, want the latest notification", last.Alert.Name)
+	}
+
+	close(release)
+	drain(t, d)
+	if len(o.sent)+len(o.letters) != total {
+		t.Errorf("%d sent and %d dead-lettered, want %d in total", len(o.sent), len(o.letters), total)
+	}
+}
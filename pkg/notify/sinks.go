@@ -0,0 +1,245 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+	"github.com/yourusername/k8s-controller-tutorial/pkg/version"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body as sha256=<hex>
+const SignatureHeader = "X-Signature-256"
+
+// Sink delivers one notification
+type Sink interface {
+	Send(ctx context.Context, alert alerts.Alert) error
+}
+
+// permanentError is a failure that a retry cannot fix, such as a rejected
+// request
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// IsPermanent reports whether err should not be retried
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// NewSink creates the sink configured in c; c must be valid. The Slack,
+// webhook and PagerDuty sinks send their requests with client, or with a
+// client of their own if it is nil; the retry timeout bounds every attempt
+// either way.
+func NewSink(c SinkConfig, client *http.Client) (Sink, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+	switch {
+	case c.Slack != nil:
+		t, err := newTemplates(c.Template, TemplateConfig{Title: "*" + DefaultTitle + "*"})
+		return &slackSink{config: *c.Slack, templates: t, client: client}, err
+	case c.Webhook != nil:
+		t, err := newTemplates(c.Template, TemplateConfig{})
+		return &webhookSink{config: *c.Webhook, templates: t, client: client}, err
+	case c.Email != nil:
+		t, err := newTemplates(c.Template, TemplateConfig{})
+		return &emailSink{config: *c.Email, templates: t}, err
+	case c.PagerDuty != nil:
+		t, err := newTemplates(c.Template, TemplateConfig{})
+		config := *c.PagerDuty
+		if config.URL == "" {
+			config.URL = DefaultPagerDutyURL
+		}
+		return &pagerDutySink{config: config, templates: t, client: client}, err
+	}
+	return nil, fmt.Errorf("no sink configured")
+}
+
+// post sends a JSON body. Timeouts, 429 and 5xx answers can be retried;
+// other answers outside 2xx are permanent failures.
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "k8s-controller-tutorial/"+version.Get().Version)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(answer)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return &permanentError{err: err}
+}
+
+// slackSink posts to a Slack incoming webhook
+type slackSink struct {
+	config    SlackConfig
+	templates *templates
+	client    *http.Client
+}
+
+// slackMessage is the body of an incoming webhook request
+type slackMessage struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel,omitempty"`
+}
+
+func (s *slackSink) Send(ctx context.Context, alert alerts.Alert) error {
+	title, text, err := s.templates.render(alert)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	body, _ := json.Marshal(slackMessage{
+		Text:    title + "\n" + text,
+		Channel: s.config.Channel,
+	})
+	return post(ctx, s.client, s.config.WebhookURL, body, nil)
+}
+
+// WebhookPayload is the body of generic webhook notifications
+type WebhookPayload struct {
+	Version   string       `json:"version"`
+	Timestamp time.Time    `json:"timestamp"`
+	Title     string       `json:"title"`
+	Text      string       `json:"text"`
+	Alert     alerts.Alert `json:"alert"`
+}
+
+// webhookSink posts a WebhookPayload, signed when a secret is set
+type webhookSink struct {
+	config    WebhookConfig
+	templates *templates
+	client    *http.Client
+}
+
+func (s *webhookSink) Send(ctx context.Context, alert alerts.Alert) error {
+	title, text, err := s.templates.render(alert)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	body, _ := json.Marshal(WebhookPayload{
+		Version:   "1",
+		Timestamp: time.Now().UTC(),
+		Title:     title,
+		Text:      text,
+		Alert:     alert,
+	})
+
+	headers := make(map[string]string, len(s.config.Headers)+1)
+	for name, value := range s.config.Headers {
+		headers[name] = value
+	}
+	if s.config.Secret != "" {
+		headers[SignatureHeader] = Sign([]byte(s.config.Secret), body)
+	}
+	return post(ctx, s.client, s.config.URL, body, headers)
+}
+
+// Sign returns the X-Signature-256 value of body: sha256= and the hex
+// HMAC-SHA256 of body with secret. Receivers recompute it over the raw body
+// and compare with hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// pagerDutySink sends PagerDuty Events API v2 trigger and resolve events
+type pagerDutySink struct {
+	config    PagerDutyConfig
+	templates *templates
+	client    *http.Client
+}
+
+// pagerDutyEvent is a PagerDuty Events API v2 event
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+func (s *pagerDutySink) Send(ctx context.Context, alert alerts.Alert) error {
+	// The same key triggers and resolves one incident
+	event := pagerDutyEvent{
+		RoutingKey:  s.config.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    strings.Join([]string{alert.Rule, alert.Namespace, alert.Kind, alert.Name}, "/"),
+	}
+	if alert.State == alerts.StateResolved {
+		event.EventAction = "resolve"
+	} else {
+		title, text, err := s.templates.render(alert)
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		// PagerDuty rejects summaries over 1024 characters
+		if len(title) > 1024 {
+			title = title[:1021] + "..."
+		}
+		timestamp := alert.ActiveAt
+		if alert.FiredAt != nil {
+			timestamp = *alert.FiredAt
+		}
+		event.Payload = &pagerDutyPayload{
+			Summary:   title,
+			Source:    alert.Namespace + "/" + strings.ToLower(alert.Kind) + "/" + alert.Name,
+			Severity:  alert.Severity,
+			Timestamp: timestamp,
+			Component: alert.Name,
+			Group:     alert.Namespace,
+			Class:     alert.Rule,
+			CustomDetails: map[string]interface{}{
+				"text":   text,
+				"labels": alert.Labels,
+			},
+		}
+	}
+
+	body, _ := json.Marshal(event)
+	return post(ctx, s.client, s.config.URL, body, nil)
+}
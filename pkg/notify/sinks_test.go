@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// request is what a test server received
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestServer answers every request with status and records it
+func newTestServer(t *testing.T, status int) (*httptest.Server, <-chan request) {
+	t.Helper()
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// testAlert returns a firing alert for deployment shop/web
+func testAlert() alerts.Alert {
+	firedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return alerts.Alert{
+		Rule:      "NotReady",
+		State:     alerts.StateFiring,
+		Severity:  "critical",
+		Namespace: "shop",
+		Kind:      "Deployment",
+		Name:      "web",
+		Resource:  "deployments",
+		Message:   "1/2 replicas ready",
+		ActiveAt:  firedAt.Add(-time.Minute),
+		FiredAt:   &firedAt,
+	}
+}
+
+// newTestSink creates the sink configured in c with the default client
+func newTestSink(t *testing.T, c SinkConfig) Sink {
+	t.Helper()
+	sink, err := NewSink(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func TestPostClassifiesAnswers(t *testing.T) {
+	tests := []struct {
+		status    int
+		fails     bool
+		permanent bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusAccepted},
+		{status: http.StatusTooManyRequests, fails: true},
+		{status: http.StatusInternalServerError, fails: true},
+		{status: http.StatusServiceUnavailable, fails: true},
+		{status: http.StatusBadRequest, fails: true, permanent: true},
+		{status: http.StatusForbidden, fails: true, permanent: true},
+		{status: http.StatusNotFound, fails: true, permanent: true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server, _ := newTestServer(t, tt.status)
+			sink := newTestSink(t, SinkConfig{Name: "hook", Webhook: &WebhookConfig{URL: server.URL}})
+
+			err := sink.Send(context.Background(), testAlert())
+			if (err != nil) != tt.fails {
+				t.Fatalf("Send() = %v, want failure %v", err, tt.fails)
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, IsPermanent(err), tt.permanent)
+			}
+		})
+	}
+}
+
+func TestSinkUsesInjectedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(SinkConfig{Name: "hook", Webhook: &WebhookConfig{URL: server.URL}}, &http.Client{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Send(context.Background(), testAlert())
+	if err == nil || !strings.Contains(err.Error(), "Client.Timeout") {
+		t.Fatalf("Send() = %v, want the client's timeout", err)
+	}
+	if IsPermanent(err) {
+		t.Errorf("a timeout must be retried")
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	sink := newTestSink(t, SinkConfig{Name: "slack", Slack: &SlackConfig{WebhookURL: server.URL, Channel: "#shop"}})
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	var msg slackMessage
+	if err := json.Unmarshal(req.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Channel != "#shop" {
+		t.Errorf("channel = %q, want #shop", msg.Channel)
+	}
+	if !strings.HasPrefix(msg.Text, "*") || !strings.Contains(msg.Text, "NotReady") || !strings.Contains(msg.Text, "shop") {
+		t.Errorf("unexpected text %q", msg.Text)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestWebhookSinkSignsBody(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusNoContent)
+	secret := "s3cr3t"
+	sink := newTestSink(t, SinkConfig{Name: "hook", Webhook: &WebhookConfig{
+		URL:     server.URL,
+		Secret:  secret,
+		Headers: map[string]string{"X-Team": "shop"},
+	}})
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	signature := req.header.Get(SignatureHeader)
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("%s = %q", SignatureHeader, signature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign([]byte(secret), req.body))) {
+		t.Errorf("%s does not match the body", SignatureHeader)
+	}
+	if hmac.Equal([]byte(signature), []byte(Sign([]byte("other"), req.body))) {
+		t.Errorf("%s matches with another secret", SignatureHeader)
+	}
+	if got := req.header.Get("X-Team"); got != "shop" {
+		t.Errorf("X-Team = %q, want shop", got)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Version != "1" || payload.Alert.Rule != "NotReady" || payload.Title == "" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhookSinkUnsigned(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	sink := newTestSink(t, SinkConfig{Name: "hook", Webhook: &WebhookConfig{URL: server.URL}})
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if req := <-requests; req.header.Get(SignatureHeader) != "" {
+		t.Errorf("unsigned webhook sent %s", SignatureHeader)
+	}
+}
+
+func TestPagerDutySinkTriggersAndResolves(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusAccepted)
+	sink := newTestSink(t, SinkConfig{Name: "pd", PagerDuty: &PagerDutyConfig{RoutingKey: "rk", URL: server.URL}})
+
+	alert := testAlert()
+	if err := sink.Send(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	var trigger pagerDutyEvent
+	if err := json.Unmarshal((<-requests).body, &trigger); err != nil {
+		t.Fatal(err)
+	}
+
+	resolvedAt := alert.FiredAt.Add(time.Minute)
+	alert.State = alerts.StateResolved
+	alert.ResolvedAt = &resolvedAt
+	if err := sink.Send(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	var resolve pagerDutyEvent
+	if err := json.Unmarshal((<-requests).body, &resolve); err != nil {
+		t.Fatal(err)
+	}
+
+	dedupKey := "NotReady/shop/Deployment/web"
+	if trigger.EventAction != "trigger" || trigger.DedupKey != dedupKey || trigger.RoutingKey != "rk" {
+		t.Errorf("unexpected trigger %+v", trigger)
+	}
+	if trigger.Payload == nil || trigger.Payload.Severity != "critical" || trigger.Payload.Source != "shop/deployment/web" {
+		t.Errorf("unexpected trigger payload %+v", trigger.Payload)
+	} else if !trigger.Payload.Timestamp.Equal(*testAlert().FiredAt) {
+		t.Errorf("payload timestamp = %v, want the firing time", trigger.Payload.Timestamp)
+	}
+	if resolve.EventAction != "resolve" || resolve.DedupKey != dedupKey || resolve.Payload != nil {
+		t.Errorf("unexpected resolve %+v", resolve)
+	}
+}
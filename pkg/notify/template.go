@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/alerts"
+)
+
+// Default templates, used for fields a sink does not override
+const (
+	DefaultTitle = `[{{ .State | upper }}] {{ .Rule }}: {{ .Namespace }}/{{ .Name }}`
+	DefaultText  = `{{ .Kind }} {{ .Namespace }}/{{ .Name }}: {{ .Message }}
+{{- if .Description }}
+{{ .Description }}{{ end }}
+Severity: {{ .Severity }}
+{{- range $key, $value := .Labels }}
+{{ $key }}: {{ $value }}{{ end }}`
+)
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// templates renders the title and text of a notification
+type templates struct {
+	title *template.Template
+	text  *template.Template
+}
+
+// newTemplates parses the templates of config, falling back to defaults and
+// then to DefaultTitle and DefaultText
+func newTemplates(config, defaults TemplateConfig) (*templates, error) {
+	parse := func(name string, sources ...string) (*template.Template, error) {
+		for _, source := range sources {
+			if source == "" {
+				continue
+			}
+			t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+			if err != nil {
+				return nil, fmt.Errorf("template.%s: %v", name, err)
+			}
+			return t, nil
+		}
+		return nil, nil
+	}
+
+	title, err := parse("title", config.Title, defaults.Title, DefaultTitle)
+	if err != nil {
+		return nil, err
+	}
+	text, err := parse("text", config.Text, defaults.Text, DefaultText)
+	if err != nil {
+		return nil, err
+	}
+	return &templates{title: title, text: text}, nil
+}
+
+// render returns the title and text for alert
+func (t *templates) render(alert alerts.Alert) (string, string, error) {
+	var title, text strings.Builder
+	if err := t.title.Execute(&title, alert); err != nil {
+		return "", "", fmt.Errorf("failed to render title: %v", err)
+	}
+	if err := t.text.Execute(&text, alert); err != nil {
+		return "", "", fmt.Errorf("failed to render text: %v", err)
+	}
+	return title.String(), text.String(), nil
+}
@@ -0,0 +1,171 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of one path, keyed by lowercase method
+type PathItem map[string]*Operation
+
+// Operation describes one method on a path
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Response describes a response for one status code
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]*Header   `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes an authentication method
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names security schemes that apply to an operation. An
+// empty requirement makes authentication optional.
+type SecurityRequirement map[string][]string
+
+// NewDocument creates an empty document
+func NewDocument(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+}
+
+// pathParam matches {name} segments, which the router and OpenAPI write the same way
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// AddOperation adds an operation. Path parameters missing from the operation
+// are added as required string parameters.
+func (d *Document) AddOperation(method, path string, op *Operation) error {
+	method = strings.ToLower(method)
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	if _, exists := item[method]; exists {
+		return fmt.Errorf("duplicate operation %s %s", strings.ToUpper(method), path)
+	}
+
+	declared := make(map[string]bool)
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+	var params []Parameter
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !declared[match[1]] {
+			params = append(params, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	op.Parameters = append(params, op.Parameters...)
+
+	item[method] = op
+	return nil
+}
+
+// Schema returns the schema for the type of v. Named struct types are added
+// to the document's components and referenced.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+// Check reports routes without an operation and operations without a route,
+// so the document cannot drift from the route table
+func (d *Document) Check(routes []router.Route) error {
+	documented := make(map[string]bool)
+	for path, item := range d.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var problems []string
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if !documented[key] {
+			problems = append(problems, "route "+key+" is not documented")
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		problems = append(problems, "operation "+key+" has no route")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("OpenAPI document does not match routes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
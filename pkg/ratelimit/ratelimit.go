@@ -0,0 +1,273 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"golang.org/x/time/rate"
+
+	"github.com/yourusername/k8s-controller-tutorial/pkg/router"
+)
+
+// sweepInterval is how often idle buckets are removed
+const sweepInterval = time.Minute
+
+// Limit is a token bucket: Rate tokens per second, up to Burst at once.
+// The zero Limit allows everything.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// ParseLimit parses RATE/UNIT[:BURST], for example 10/s, 600/m:50 or 1/h.
+// "none" disables the limit. Without a burst, the burst is the rate rounded
+// up to whole requests per unit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "none" {
+		return Limit{}, nil
+	}
+
+	spec, burstSpec, hasBurst := strings.Cut(s, ":")
+	count, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected RATE/UNIT[:BURST]", s)
+	}
+
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: rate must be a positive number", s)
+	}
+
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit %q: unit must be s, m or h", s)
+	}
+
+	burst := int(math.Ceil(n))
+	if hasBurst {
+		burst, err = strconv.Atoi(burstSpec)
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", s)
+		}
+	}
+
+	return Limit{Rate: rate.Limit(n / per.Seconds()), Burst: burst}, nil
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// String formats the limit in the form accepted by ParseLimit
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "none"
+	}
+	n, unit := float64(l.Rate), "s"
+	if n < 1 {
+		n, unit = n*60, "m"
+	}
+	if n < 1 {
+		n, unit = n*60, "h"
+	}
+	return fmt.Sprintf("%s/%s:%d", strconv.FormatFloat(n, 'g', 4, 64), unit, l.Burst)
+}
+
+// Rejection describes why a request was limited
+type Rejection struct {
+	// Reason is ip, user or in_flight
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (r *Rejection) Error() string {
+	if r.Reason == "in_flight" {
+		return "too many requests in flight; retry later"
+	}
+	return fmt.Sprintf("rate limit per %s exceeded; retry in %s", r.Reason, r.RetryAfter.Round(time.Second))
+}
+
+// RejectFunc writes the response for a limited request. Status 429 and the
+// Retry-After header are set before it is called.
+type RejectFunc func(ctx *fasthttp.RequestCtx, rejection *Rejection)
+
+// KeyFunc returns the key a request is limited by, or "" to not limit it
+type KeyFunc func(ctx *fasthttp.RequestCtx) string
+
+// Limiter keeps one token bucket per key
+type Limiter struct {
+	limit Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewLimiter creates a keyed limiter
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Limit returns the limit applied to each key
+func (l *Limiter) Limit() Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit replaces the limit. Buckets start over, full, with the new limit.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.buckets = make(map[string]*bucket)
+}
+
+// Allow takes a token for key. When none is available it returns false and
+// how long until one is.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.limit.Enabled() {
+		return true, 0
+	}
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit.Rate, l.limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep removes buckets idle long enough to be full again; a new bucket for
+// the same key behaves the same
+func (l *Limiter) sweep(now time.Time) {
+	refill := time.Duration(float64(l.limit.Burst) / float64(l.limit.Rate) * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Middleware limits requests by key. Requests with an empty key pass.
+func (l *Limiter) Middleware(reason string, key KeyFunc, rejected RejectFunc) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			k := key(ctx)
+			if k == "" {
+				next(ctx)
+				return
+			}
+			if ok, retryAfter := l.Allow(k); !ok {
+				reject(ctx, &Rejection{Reason: reason, RetryAfter: retryAfter}, rejected)
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// InFlight caps the number of requests handled at the same time
+type InFlight struct {
+	slots atomic.Pointer[chan struct{}]
+}
+
+// NewInFlight creates a cap of max concurrent requests; 0 disables it
+func NewInFlight(max int) *InFlight {
+	f := &InFlight{}
+	f.SetMax(max)
+	return f
+}
+
+// SetMax changes the cap; 0 disables it. Requests already in flight are not
+// counted against the new cap.
+func (f *InFlight) SetMax(max int) {
+	if max <= 0 {
+		f.slots.Store(nil)
+		return
+	}
+	slots := make(chan struct{}, max)
+	f.slots.Store(&slots)
+}
+
+// Middleware rejects requests while the cap is reached
+func (f *InFlight) Middleware(rejected RejectFunc) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			slots := f.slots.Load()
+			if slots == nil {
+				next(ctx)
+				return
+			}
+
+			// The slot is released to the channel it was taken from, even if
+			// the cap changes meanwhile
+			select {
+			case *slots <- struct{}{}:
+				defer func() { <-*slots }()
+				next(ctx)
+			default:
+				reject(ctx, &Rejection{Reason: "in_flight", RetryAfter: time.Second}, rejected)
+			}
+		}
+	}
+}
+
+// ClientIP returns the address of the connected client
+func ClientIP(ctx *fasthttp.RequestCtx) string {
+	return ctx.RemoteIP().String()
+}
+
+func reject(ctx *fasthttp.RequestCtx, rejection *Rejection, rejected RejectFunc) {
+	seconds := int(math.Ceil(rejection.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, strconv.Itoa(seconds))
+	ctx.SetStatusCode(fasthttp.StatusTooManyRequests)
+	if rejected != nil {
+		rejected(ctx, rejection)
+		return
+	}
+	ctx.Error(rejection.Error(), fasthttp.StatusTooManyRequests)
+}
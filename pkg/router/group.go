@@ -0,0 +1,52 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Group registers routes under a common prefix and wraps them in the group's
+// middleware. Middleware must be added before the routes it should apply to.
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []Middleware
+}
+
+// Prefix returns the group's path prefix
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Use adds middleware to routes registered on the group afterwards
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Group creates a nested group that inherits this group's middleware
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix + strings.TrimSuffix(prefix, "/"),
+		middleware: append(append([]Middleware(nil), g.middleware...), middleware...),
+	}
+}
+
+// Handle registers a handler for a method and a path relative to the prefix
+func (g *Group) Handle(method, path string, handler fasthttp.RequestHandler) {
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		handler = g.middleware[i](handler)
+	}
+	g.router.Handle(method, g.prefix+path, handler)
+}
+
+// GET registers a GET handler
+func (g *Group) GET(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodGet, path, handler)
+}
+
+// POST registers a POST handler
+func (g *Group) POST(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodPost, path, handler)
+}
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Middleware wraps a handler with additional behaviour
+type Middleware func(next fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// MatchedRouteKey is the ctx user value holding the pattern of the matched route
+const MatchedRouteKey = "router.matched_route"
+
+// Route describes a registered route
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Router dispatches fasthttp requests by method and path. Paths may contain
+// parameters written as {name}, which are stored as ctx user values.
+type Router struct {
+	root       *node
+	routes     []Route
+	middleware []Middleware
+
+	// NotFound handles requests that match no route
+	NotFound fasthttp.RequestHandler
+
+	// MethodNotAllowed handles requests whose path matches but method does not.
+	// The Allow header is set before it is called.
+	MethodNotAllowed fasthttp.RequestHandler
+
+	// GlobalOPTIONS handles OPTIONS requests for paths without an explicit
+	// OPTIONS route. The Allow header is set before it is called.
+	GlobalOPTIONS fasthttp.RequestHandler
+
+	// RedirectTrailingSlash redirects /path/ to /path (and vice versa) when
+	// only the other form is registered
+	RedirectTrailingSlash bool
+}
+
+// New creates a router with trailing-slash redirects enabled
+func New() *Router {
+	return &Router{
+		root:                  &node{},
+		RedirectTrailingSlash: true,
+	}
+}
+
+// Use adds middleware that wraps every request, including not-found and
+// method-not-allowed responses
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Group creates a route group under prefix with its own middleware
+func (r *Router) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		router:     r,
+		prefix:     strings.TrimSuffix(prefix, "/"),
+		middleware: middleware,
+	}
+}
+
+// Handle registers a handler for a method and path pattern
+func (r *Router) Handle(method, path string, handler fasthttp.RequestHandler) {
+	if path == "" || path[0] != '/' {
+		panic("router: path must begin with '/': " + path)
+	}
+
+	n := r.root
+	for _, segment := range splitPath(path) {
+		n = n.child(segment)
+	}
+	if n.handlers == nil {
+		n.handlers = make(map[string]fasthttp.RequestHandler)
+	}
+	if _, exists := n.handlers[method]; exists {
+		panic("router: duplicate route " + method + " " + path)
+	}
+	n.handlers[method] = handler
+	n.pattern = path
+
+	r.routes = append(r.routes, Route{Method: method, Path: path})
+}
+
+// GET registers a GET handler
+func (r *Router) GET(path string, handler fasthttp.RequestHandler) {
+	r.Handle(fasthttp.MethodGet, path, handler)
+}
+
+// POST registers a POST handler
+func (r *Router) POST(path string, handler fasthttp.RequestHandler) {
+	r.Handle(fasthttp.MethodPost, path, handler)
+}
+
+// Routes returns all registered routes sorted by path and method
+func (r *Router) Routes() []Route {
+	routes := append([]Route(nil), r.routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Allowed returns the methods registered for a request path, or nil when no
+// route matches the path
+func (r *Router) Allowed(path string) []string {
+	n, _ := r.root.match(splitPath(path), nil)
+	if n == nil {
+		return nil
+	}
+	return n.methods()
+}
+
+// Handler returns the request handler, wrapped in the router's middleware
+func (r *Router) Handler() fasthttp.RequestHandler {
+	handler := fasthttp.RequestHandler(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler
+}
+
+// dispatch finds the route for a request and calls its handler
+func (r *Router) dispatch(ctx *fasthttp.RequestCtx) {
+	path := string(ctx.Path())
+	method := string(ctx.Method())
+
+	n, params := r.root.match(splitPath(path), nil)
+	if n == nil {
+		if r.RedirectTrailingSlash && r.redirectTrailingSlash(ctx, path) {
+			return
+		}
+		r.notFound(ctx)
+		return
+	}
+
+	for _, p := range params {
+		ctx.SetUserValue(p.name, p.value)
+	}
+	ctx.SetUserValue(MatchedRouteKey, n.pattern)
+
+	if handler, ok := n.handler(method); ok {
+		handler(ctx)
+		return
+	}
+
+	allow := strings.Join(n.methods(), ", ")
+	if method == fasthttp.MethodOptions {
+		ctx.Response.Header.Set("Allow", allow+", "+fasthttp.MethodOptions)
+		if r.GlobalOPTIONS != nil {
+			r.GlobalOPTIONS(ctx)
+		} else {
+			ctx.SetStatusCode(fasthttp.StatusNoContent)
+		}
+		return
+	}
+
+	ctx.Response.Header.Set("Allow", allow)
+	if r.MethodNotAllowed != nil {
+		r.MethodNotAllowed(ctx)
+	} else {
+		plainError(ctx, fasthttp.StatusMethodNotAllowed)
+	}
+}
+
+// redirectTrailingSlash redirects to the other trailing-slash form of path
+// when that form is registered
+func (r *Router) redirectTrailingSlash(ctx *fasthttp.RequestCtx, path string) bool {
+	if path == "/" {
+		return false
+	}
+
+	alternative := path + "/"
+	if strings.HasSuffix(path, "/") {
+		alternative = strings.TrimSuffix(path, "/")
+	}
+	if n, _ := r.root.match(splitPath(alternative), nil); n == nil {
+		return false
+	}
+
+	// 308 keeps the method and body for non-GET requests
+	status := fasthttp.StatusMovedPermanently
+	if !ctx.IsGet() && !ctx.IsHead() {
+		status = fasthttp.StatusPermanentRedirect
+	}
+
+	uri := alternative
+	if query := ctx.URI().QueryString(); len(query) > 0 {
+		uri += "?" + string(query)
+	}
+	ctx.Redirect(uri, status)
+	return true
+}
+
+func (r *Router) notFound(ctx *fasthttp.RequestCtx) {
+	if r.NotFound != nil {
+		r.NotFound(ctx)
+		return
+	}
+	plainError(ctx, fasthttp.StatusNotFound)
+}
+
+// plainError answers with status and its text. Unlike ctx.Error it keeps the
+// headers already set, such as Allow and those of middleware.
+func plainError(ctx *fasthttp.RequestCtx, status int) {
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("text/plain; charset=utf-8")
+	ctx.SetBodyString(fasthttp.StatusMessage(status))
+}
+
+// Param returns the value of a path parameter
+func Param(ctx *fasthttp.RequestCtx, name string) string {
+	value, _ := ctx.UserValue(name).(string)
+	return value
+}
+
+// MatchedRoute returns the pattern of the route that handled the request, or
+// an empty string when no route matched
+func MatchedRoute(ctx *fasthttp.RequestCtx) string {
+	pattern, _ := ctx.UserValue(MatchedRouteKey).(string)
+	return pattern
+}
+
+// splitPath splits a path into segments, keeping a trailing empty segment for
+// paths that end in a slash so /path and /path/ are distinct
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
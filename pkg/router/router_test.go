@@ -0,0 +1,206 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// respond returns a handler that writes body and the path parameters
+func respond(body string, params ...string) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		values := []string{body}
+		for _, name := range params {
+			values = append(values, name+"="+Param(ctx, name))
+		}
+		ctx.SetBodyString(strings.Join(values, " "))
+	}
+}
+
+// newTestRouter returns a router with the routes the tests request
+func newTestRouter() *Router {
+	r := New()
+	r.GET("/", respond("root"))
+	r.GET("/health", respond("health"))
+	r.POST("/alerts/test", respond("test alert"))
+	r.GET("/namespaces/{namespace}/deployments", respond("list", "namespace"))
+	r.GET("/namespaces/{namespace}/deployments/{name}", respond("get", "namespace", "name"))
+	r.GET("/namespaces/{namespace}/deployments/status", respond("status", "namespace"))
+	r.GET("/docs/", respond("docs"))
+	r.Handle(fasthttp.MethodHead, "/explicit", respond("explicit head"))
+	r.GET("/explicit", respond("explicit get"))
+	return r
+}
+
+// serve sends a request to handler
+func serve(handler fasthttp.RequestHandler, method, uri string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	handler(ctx)
+	return ctx
+}
+
+func TestDispatch(t *testing.T) {
+	handler := newTestRouter().Handler()
+
+	tests := []struct {
+		name     string
+		method   string
+		uri      string
+		code     int
+		body     string
+		allow    string
+		location string
+		route    string
+	}{
+		{name: "root", method: "GET", uri: "/", code: 200, body: "root", route: "/"},
+		{name: "static", method: "GET", uri: "/health", code: 200, body: "health", route: "/health"},
+		{name: "one param", method: "GET", uri: "/namespaces/shop/deployments", code: 200, body: "list namespace=shop"},
+		{name: "two params", method: "GET", uri: "/namespaces/shop/deployments/web", code: 200, body: "get namespace=shop name=web",
+			route: "/namespaces/{namespace}/deployments/{name}"},
+		{name: "static before param", method: "GET", uri: "/namespaces/shop/deployments/status", code: 200, body: "status namespace=shop"},
+		{name: "query is ignored", method: "GET", uri: "/health?verbose=1", code: 200, body: "health"},
+		{name: "unknown path", method: "GET", uri: "/nope", code: 404},
+		{name: "too deep", method: "GET", uri: "/namespaces/shop/deployments/web/pods", code: 404},
+		{name: "wrong method", method: "POST", uri: "/health", code: 405, allow: "GET, HEAD", route: "/health"},
+		{name: "GET on POST route", method: "GET", uri: "/alerts/test", code: 405, allow: "POST"},
+		{name: "HEAD uses GET", method: "HEAD", uri: "/health", code: 200, body: "health", route: "/health"},
+		{name: "HEAD on POST route", method: "HEAD", uri: "/alerts/test", code: 405, allow: "POST"},
+		{name: "explicit HEAD", method: "HEAD", uri: "/explicit", code: 200, body: "explicit head"},
+		{name: "OPTIONS", method: "OPTIONS", uri: "/namespaces/shop/deployments", code: 204, allow: "GET, HEAD, OPTIONS"},
+		{name: "OPTIONS with explicit HEAD", method: "OPTIONS", uri: "/explicit", code: 204, allow: "GET, HEAD, OPTIONS"},
+		{name: "OPTIONS unknown path", method: "OPTIONS", uri: "/nope", code: 404},
+		{name: "remove trailing slash", method: "GET", uri: "/health/?verbose", code: 301, location: "/health?verbose"},
+		{name: "add trailing slash", method: "GET", uri: "/docs", code: 301, location: "/docs/"},
+		{name: "redirect keeps POST", method: "POST", uri: "/alerts/test/", code: 308, location: "/alerts/test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := serve(handler, tt.method, tt.uri)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.uri, ctx.Response.StatusCode(), tt.code)
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("body = %q, want %q", ctx.Response.Body(), tt.body)
+			}
+			if got := string(ctx.Response.Header.Peek("Allow")); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+			if tt.location != "" {
+				if got := string(ctx.Response.Header.Peek("Location")); !strings.HasSuffix(got, tt.location) {
+					t.Errorf("Location = %q, want %q", got, tt.location)
+				}
+			}
+			if tt.route != "" && MatchedRoute(ctx) != tt.route {
+				t.Errorf("matched route = %q, want %q", MatchedRoute(ctx), tt.route)
+			}
+		})
+	}
+}
+
+func TestCustomHandlers(t *testing.T) {
+	r := newTestRouter()
+	r.NotFound = respond("custom not found")
+	r.MethodNotAllowed = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.SetBodyString("custom 405, allow " + string(ctx.Response.Header.Peek("Allow")))
+	}
+	r.GlobalOPTIONS = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+		ctx.SetBodyString("custom options, allow " + string(ctx.Response.Header.Peek("Allow")))
+	}
+	r.RedirectTrailingSlash = false
+	handler := r.Handler()
+
+	for _, tt := range []struct{ method, uri, body string }{
+		{"GET", "/nope", "custom not found"},
+		{"GET", "/health/", "custom not found"},
+		{"DELETE", "/health", "custom 405, allow GET, HEAD"},
+		{"OPTIONS", "/alerts/test", "custom options, allow POST, OPTIONS"},
+	} {
+		if ctx := serve(handler, tt.method, tt.uri); string(ctx.Response.Body()) != tt.body {
+			t.Errorf("%s %s = %q, want %q", tt.method, tt.uri, ctx.Response.Body(), tt.body)
+		}
+	}
+}
+
+// trace returns middleware that appends name to the X-Trace header before
+// calling next
+func trace(name string) Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Response.Header.Add("X-Trace", name)
+			next(ctx)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	r := New()
+	r.Use(trace("global1"), trace("global2"))
+	api := r.Group("/api/", trace("api"))
+	api.GET("/before", respond("before"))
+	api.Use(trace("api-late"))
+	v1 := api.Group("/v1", trace("v1"))
+	v1.GET("/items/{id}", respond("item", "id"))
+	api.GET("/after", respond("after"))
+	handler := r.Handler()
+
+	tests := []struct {
+		uri   string
+		trace string
+		body  string
+	}{
+		{uri: "/api/before", trace: "global1,global2,api", body: "before"},
+		{uri: "/api/v1/items/7", trace: "global1,global2,api,api-late,v1", body: "item id=7"},
+		{uri: "/api/after", trace: "global1,global2,api,api-late", body: "after"},
+		// Router middleware also wraps responses without a route
+		{uri: "/api/missing", trace: "global1,global2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			ctx := serve(handler, fasthttp.MethodGet, tt.uri)
+			var trace []string
+			ctx.Response.Header.VisitAll(func(key, value []byte) {
+				if string(key) == "X-Trace" {
+					trace = append(trace, string(value))
+				}
+			})
+			if strings.Join(trace, ",") != tt.trace {
+				t.Errorf("middleware ran as %v, want %s", trace, tt.trace)
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("body = %q, want %q", ctx.Response.Body(), tt.body)
+			}
+		})
+	}
+}
+
+func TestRoutesAndAllowed(t *testing.T) {
+	r := newTestRouter()
+
+	routes := r.Routes()
+	if len(routes) != 9 || routes[0] != (Route{Method: "GET", Path: "/"}) {
+		t.Errorf("unexpected routes %v", routes)
+	}
+	if got := strings.Join(r.Allowed("/namespaces/a/deployments/b"), ","); got != "GET,HEAD" {
+		t.Errorf("Allowed() = %s, want GET,HEAD", got)
+	}
+	if r.Allowed("/nope") != nil {
+		t.Errorf("Allowed() of an unknown path is not nil")
+	}
+}
+
+func TestDuplicateRoutePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a route twice did not panic")
+		}
+	}()
+	r := New()
+	r.GET("/health", respond("a"))
+	r.GET("/health", respond("b"))
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// node is a path segment in the routing trie
+type node struct {
+	static    map[string]*node
+	param     *node
+	paramName string
+
+	handlers map[string]fasthttp.RequestHandler
+	pattern  string
+}
+
+// param is a matched path parameter
+type param struct {
+	name  string
+	value string
+}
+
+// child returns the child for a pattern segment, creating it if needed
+func (n *node) child(segment string) *node {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		name := segment[1 : len(segment)-1]
+		if name == "" {
+			panic("router: empty path parameter name")
+		}
+		if n.param == nil {
+			n.param = &node{paramName: name}
+		} else if n.param.paramName != name {
+			panic("router: conflicting path parameter names {" + n.param.paramName + "} and {" + name + "}")
+		}
+		return n.param
+	}
+
+	if n.static == nil {
+		n.static = make(map[string]*node)
+	}
+	child, ok := n.static[segment]
+	if !ok {
+		child = &node{}
+		n.static[segment] = child
+	}
+	return child
+}
+
+// match walks the trie for path segments, preferring static segments over
+// parameters, and returns the node that has handlers
+func (n *node) match(segments []string, params []param) (*node, []param) {
+	if len(segments) == 0 {
+		if len(n.handlers) == 0 {
+			return nil, nil
+		}
+		return n, params
+	}
+
+	segment := segments[0]
+	if child, ok := n.static[segment]; ok {
+		if found, foundParams := child.match(segments[1:], params); found != nil {
+			return found, foundParams
+		}
+	}
+	if n.param != nil && segment != "" {
+		withParam := append(params[:len(params):len(params)], param{name: n.param.paramName, value: segment})
+		if found, foundParams := n.param.match(segments[1:], withParam); found != nil {
+			return found, foundParams
+		}
+	}
+	return nil, nil
+}
+
+// handler returns the handler for method. HEAD falls back to the GET
+// handler; fasthttp leaves out the body of responses to HEAD requests.
+func (n *node) handler(method string) (fasthttp.RequestHandler, bool) {
+	if handler, ok := n.handlers[method]; ok {
+		return handler, true
+	}
+	if method == fasthttp.MethodHead {
+		handler, ok := n.handlers[fasthttp.MethodGet]
+		return handler, ok
+	}
+	return nil, false
+}
+
+// methods returns the sorted methods the node answers: the registered ones,
+// and HEAD with GET
+func (n *node) methods() []string {
+	methods := make([]string, 0, len(n.handlers)+1)
+	for method := range n.handlers {
+		methods = append(methods, method)
+	}
+	_, get := n.handlers[fasthttp.MethodGet]
+	if _, head := n.handlers[fasthttp.MethodHead]; get && !head {
+		methods = append(methods, fasthttp.MethodHead)
+	}
+	sort.Strings(methods)
+	return methods
+}
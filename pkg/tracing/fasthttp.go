@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier adapts fasthttp request headers to a propagation.TextMapCarrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// StartServerSpan starts a server span for a fasthttp request, continuing any
+// trace propagated by the caller. The span must be ended with EndServerSpan.
+func StartServerSpan(ctx *fasthttp.RequestCtx, name string) (context.Context, trace.Span) {
+	parent := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier{&ctx.Request.Header})
+
+	return Tracer().Start(parent, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", string(ctx.Method())),
+			attribute.String("url.path", string(ctx.Path())),
+			attribute.String("client.address", ctx.RemoteIP().String()),
+			attribute.String("user_agent.original", string(ctx.UserAgent())),
+		),
+	)
+}
+
+// EndServerSpan records the response status on the span and ends it
+func EndServerSpan(ctx *fasthttp.RequestCtx, span trace.Span) {
+	status := ctx.Response.StatusCode()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= fasthttp.StatusInternalServerError {
+		span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+	}
+	span.End()
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName identifies spans created by this module
+const InstrumentationName = "github.com/yourusername/k8s-controller-tutorial"
+
+// Supported exporters
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config holds tracing settings
+type Config struct {
+	// Exporter is one of none, otlp, stdout or file
+	Exporter string
+
+	// Endpoint is the OTLP/HTTP collector address (host:port). When empty the
+	// standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string
+	Insecure bool
+
+	// FilePath is where spans are written by the file exporter
+	FilePath string
+
+	// SampleRatio is the fraction of new traces that are recorded
+	SampleRatio float64
+
+	ServiceName string
+}
+
+// ShutdownFunc flushes pending spans and releases exporter resources
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and propagator for the configured
+// exporter. With the none exporter tracing stays a no-op.
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	noop := func(context.Context) error { return nil }
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return noop, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			return noop, fmt.Errorf("file exporter requires a file path")
+		}
+		var file *os.File
+		file, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return noop, fmt.Errorf("failed to open trace file: %v", err)
+		}
+		closer = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return noop, fmt.Errorf("unknown trace exporter %q (expected none, otlp, stdout or file)", cfg.Exporter)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("failed to create %s trace exporter: %v", cfg.Exporter, err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "k8s-controller"
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer used for spans created by this module
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// WrapTransport instruments an HTTP transport so every request gets a client
+// span. It matches the signature of rest.Config.Wrap. Spans are named after
+// the method and the resource, e.g. "k8s GET deployments", so the number of
+// span names stays bounded; the path is recorded in url.path.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(pathAttribute{rt},
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "k8s " + r.Method + " " + apiResource(r.URL.Path)
+		}),
+	)
+}
+
+// pathAttribute records the request path on the span otelhttp started
+type pathAttribute struct {
+	next http.RoundTripper
+}
+
+func (t pathAttribute) RoundTrip(r *http.Request) (*http.Response, error) {
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("url.path", r.URL.Path))
+	return t.next.RoundTrip(r)
+}
+
+// namespaceSubresources are the subresources of namespaces, which would
+// otherwise be taken for a resource in the namespace
+var namespaceSubresources = map[string]bool{"status": true, "finalize": true}
+
+// apiResource returns the resource, and subresource if any, an API server
+// path refers to, e.g. deployments or pods/log. Paths outside /api and /apis
+// are reduced to their first segment, e.g. /version or /openapi.
+func apiResource(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var rest []string
+	switch {
+	case segments[0] == "api" && len(segments) >= 2:
+		rest = segments[2:]
+	case segments[0] == "apis" && len(segments) >= 3:
+		rest = segments[3:]
+	default:
+		return "/" + segments[0]
+	}
+	if len(rest) == 0 {
+		// Discovery of a group version, e.g. /apis/apps/v1
+		return "/" + strings.Join(segments, "/")
+	}
+	if rest[0] == "namespaces" && len(rest) >= 3 && !namespaceSubresources[rest[2]] {
+		rest = rest[2:]
+	}
+	if len(rest) >= 3 {
+		return rest[0] + "/" + rest[2]
+	}
+	return rest[0]
+}
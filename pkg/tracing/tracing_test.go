@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAPIResource(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/v1/pods":                                             "pods",
+		"/api/v1/namespaces":                                       "namespaces",
+		"/api/v1/namespaces/shop":                                  "namespaces",
+		"/api/v1/namespaces/shop/status":                           "namespaces/status",
+		"/api/v1/namespaces/shop/finalize":                         "namespaces/finalize",
+		"/api/v1/namespaces/shop/pods":                             "pods",
+		"/api/v1/namespaces/shop/pods/web-7d4b9-x2x9z":             "pods",
+		"/api/v1/namespaces/shop/pods/web-7d4b9-x2x9z/log":         "pods/log",
+		"/apis/apps/v1/namespaces/shop/deployments/web":            "deployments",
+		"/apis/apps/v1/namespaces/shop/deployments/web/scale":      "deployments/scale",
+		"/apis/apps/v1/deployments":                                "deployments",
+		"/apis/authorization.k8s.io/v1/subjectaccessreviews":       "subjectaccessreviews",
+		"/apis/monitoring.example.com/v1alpha1/deploymentmonitors": "deploymentmonitors",
+		"/apis/apps/v1":                                            "/apis/apps/v1",
+		"/api/v1":                                                  "/api/v1",
+		"/apis":                                                    "/apis",
+		"/api":                                                     "/api",
+		"/version":                                                 "/version",
+		"/openapi/v3/apis/apps/v1":                                 "/openapi",
+		"/":                                                        "/",
+	} {
+		if got := apiResource(path); got != want {
+			t.Errorf("apiResource(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	saved := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(saved)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: WrapTransport(http.DefaultTransport)}
+
+	for _, path := range []string{"/apis/apps/v1/namespaces/a/deployments/web", "/apis/apps/v1/namespaces/b/deployments/api"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for i, want := range []string{"/apis/apps/v1/namespaces/a/deployments/web", "/apis/apps/v1/namespaces/b/deployments/api"} {
+		if spans[i].Name() != "k8s GET deployments" {
+			t.Errorf("span name = %q, want %q", spans[i].Name(), "k8s GET deployments")
+		}
+		var path string
+		for _, attr := range spans[i].Attributes() {
+			if attr.Key == "url.path" {
+				path = attr.Value.AsString()
+			}
+		}
+		if path != want {
+			t.Errorf("url.path = %q, want %q", path, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/yourusername/k8s-controller-tutorial/pkg/version.version=v1.2.3"
+//
+// Values that are not set come from the build information Go embeds in the
+// binary: the module version and the VCS revision, time and modified flag.
+var (
+	version   string
+	commit    string
+	buildDate string
+	// dirty is "true" when the binary was built from a modified working tree
+	dirty string
+)
+
+// Info describes the running binary
+type Info struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	CommitDate string `json:"commit_date,omitempty"`
+	BuildDate  string `json:"build_date,omitempty"`
+	Dirty      bool   `json:"dirty"`
+	GoVersion  string `json:"go_version"`
+	Platform   string `json:"platform"`
+}
+
+// Get returns the version information of the running binary
+func Get() Info {
+	return info()
+}
+
+var info = sync.OnceValue(func() Info {
+	i := Info{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	i.Dirty, _ = strconv.ParseBool(dirty)
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if i.Version == "" && build.Main.Version != "(devel)" {
+			i.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if i.Commit == "" {
+					i.Commit = setting.Value
+				}
+			case "vcs.time":
+				i.CommitDate = setting.Value
+			case "vcs.modified":
+				if dirty == "" {
+					i.Dirty = setting.Value == "true"
+				}
+			}
+		}
+	}
+
+	if i.Version == "" {
+		i.Version = "dev"
+	}
+	if i.Commit == "" {
+		i.Commit = "unknown"
+	}
+	return i
+})
+
+// String formats the information on one line, e.g.
+// "v1.2.3 (commit 1a2b3c4, built 2025-01-02T15:04:05Z, go1.24.4 linux/amd64)"
+func (i Info) String() string {
+	details := []string{"commit " + shortCommit(i.Commit)}
+	if i.Dirty {
+		details[0] += "-dirty"
+	}
+	if i.BuildDate != "" {
+		details = append(details, "built "+i.BuildDate)
+	}
+	details = append(details, i.GoVersion+" "+i.Platform)
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
+
+// shortCommit abbreviates a full revision like git does
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}